@@ -4,189 +4,109 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(adaptiveThresholdCmd)
+	registerFilter(adaptiveThresholdCmd, newAdaptiveThresholdFilter)
 }
 
-var (
-	currentAdaptiveThreshold   int
-	currentAThreshold          int
-	blockSize                  int
-	cValue                     float32
-	blockSizeTracker, cTracker *gocv.Trackbar
-)
-
 var adaptiveThresholdCmd = &cobra.Command{
 	Use:   "adaptive",
 	Short: "Apply adaptive threshold to video images",
-	Long: `Apply adaptive threshold to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through adaptive threshold calculation types.
-  Use 'a' and 's' keys to page through threshold calculation types.
-  Press 'esc' to exit.
-  Press 'w' to write JPG file.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleAdaptiveThresholdCmd()
-	},
-}
-
-func handleAdaptiveThresholdCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(adaptiveThresholdWindowTitle())
-	defer window.Close()
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
+	Long:  `Apply adaptive threshold to video images.`,
+}
 
-	gray := gocv.NewMat()
-	defer gray.Close()
+type adaptiveThresholdFilter struct {
+	blockSize, c *Param
+	method, typ  *Enum
+}
 
-	blockSizeTracker = window.CreateTrackbar("block size", 255)
-	blockSizeTracker.SetPos(3)
-
-	cTracker = window.CreateTrackbar("C", 512)
-	cTracker.SetPos(256)
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// make sure we do not have any invalid values
-		validateAdaptiveThresholdTrackers()
-
-		// only works on grayscale images
-		gocv.CvtColor(img, &gray, gocv.ColorBGRAToGray)
-
-		// AdaptiveThreshold image processing filter
-		gocv.AdaptiveThreshold(gray, &processed, 255, getCurrentAdaptiveThreshold(), getCurrentAThreshold(), blockSize, cValue)
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			prevAdaptiveThreshold()
-			window.SetWindowTitle(adaptiveThresholdWindowTitle())
-		case xKey:
-			nextAdaptiveThreshold()
-			window.SetWindowTitle(adaptiveThresholdWindowTitle())
-		case aKey:
-			prevAThreshold()
-			window.SetWindowTitle(adaptiveThresholdWindowTitle())
-		case sKey:
-			nextAThreshold()
-			window.SetWindowTitle(adaptiveThresholdWindowTitle())
-		case gKey:
-			adaptiveThresholdGoCodeFragment(255.0, getCurrentAdaptiveThresholdDescription(), getCurrentAThresholdDescription(), blockSize, cValue)
-		case pKey:
-			adaptiveThresholdPythonCodeFragment(255.0, getCurrentAdaptiveThresholdDescription(), getCurrentAThresholdDescription(), blockSize, cValue)
-		case space:
-			handlePause(adaptiveThresholdWindowTitle())
-		case wKey:
-			writeFile("adaptive", processed)
-		case esc:
-			return
-		}
+func newAdaptiveThresholdFilter() Filter {
+	return &adaptiveThresholdFilter{
+		blockSize: newParam("block size", 0, 255, 3),
+		c:         newParam("C", 0, 512, 256),
+		method: &Enum{
+			Name:    "adaptive method",
+			PrevKey: zKey,
+			NextKey: xKey,
+			Options: []Option{
+				{"AdaptiveThresholdMean", int(gocv.AdaptiveThresholdMean), "ADAPTIVE_THRESH_MEAN_C"},
+				{"AdaptiveThresholdGaussian", int(gocv.AdaptiveThresholdGaussian), "ADAPTIVE_THRESH_GAUSSIAN_C"},
+			},
+		},
+		typ: newThresholdEnum(aKey, sKey, 2),
 	}
 }
 
-// blocksize has to be odd. C value ranges from -256.0 to 256.0.
-func validateAdaptiveThresholdTrackers() {
-	blockSize = ensureOdd(blockSizeTracker)
-	cValue = float32(cTracker.GetPos() - 256)
+func (f *adaptiveThresholdFilter) Name() string {
+	return "adaptive"
 }
 
-func getCurrentAdaptiveThreshold() gocv.AdaptiveThresholdType {
-	return gocv.AdaptiveThresholdType(currentAdaptiveThreshold)
+func (f *adaptiveThresholdFilter) Title() string {
+	return "AdaptiveThreshold - " + f.method.Description() + " - " + f.typ.Description() + " - CVscope"
 }
 
-func getCurrentAdaptiveThresholdDescription() string {
-	switch currentAdaptiveThreshold {
-	case 0:
-		return "AdaptiveThresholdMean"
-	case 1:
-		return "AdaptiveThresholdGaussian"
-	}
-
-	return "Unknown"
+func (f *adaptiveThresholdFilter) Params() []*Param {
+	return []*Param{f.blockSize, f.c}
 }
 
-func prevAdaptiveThreshold() {
-	currentAdaptiveThreshold--
-	if currentAdaptiveThreshold < 0 {
-		currentAdaptiveThreshold = 1
-	}
+func (f *adaptiveThresholdFilter) Enums() []*Enum {
+	return []*Enum{f.method, f.typ}
 }
 
-func nextAdaptiveThreshold() {
-	currentAdaptiveThreshold = (currentAdaptiveThreshold + 1) % 2
+// blocksize has to be odd.
+func (f *adaptiveThresholdFilter) Validate() {
+	ensureOdd(f.blockSize)
 }
 
-func getCurrentAThreshold() gocv.ThresholdType {
-	return gocv.ThresholdType(currentAThreshold)
+// cValue is the C value, which ranges from -256.0 to 256.0.
+func (f *adaptiveThresholdFilter) cValue() float32 {
+	return float32(f.c.Pos() - 256)
 }
 
-func getCurrentAThresholdDescription() string {
-	switch currentAThreshold {
-	case 0:
-		return "ThresholdBinary"
-	case 1:
-		return "ThresholdBinaryInv"
+func (f *adaptiveThresholdFilter) paramValue(p *Param) (string, bool) {
+	if p == f.c {
+		return fmt.Sprintf("%.0f", f.cValue()), true
 	}
+	return "", false
+}
 
-	return "Unknown"
+// the input is converted to grayscale
+func (f *adaptiveThresholdFilter) outputChannels(input int) int {
+	return 1
 }
 
-func prevAThreshold() {
-	currentAThreshold--
-	if currentAThreshold < 0 {
-		currentAThreshold = 1
-	}
+// the output is a mask
+func (f *adaptiveThresholdFilter) maskOutput() bool {
+	return true
 }
 
-func nextAThreshold() {
-	currentAThreshold = (currentAThreshold + 1) % 2
+func (f *adaptiveThresholdFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gray := gocv.NewMat()
+	defer gray.Close()
+
+	// only works on grayscale images
+	toGray(src, &gray)
+
+	gocv.AdaptiveThreshold(gray, dst, 255, gocv.AdaptiveThresholdType(f.method.Value()),
+		gocv.ThresholdType(f.typ.Value()), f.blockSize.Pos(), f.cValue())
 }
 
-func adaptiveThresholdWindowTitle() string {
-	return "AdaptiveThreshold - " + getCurrentAdaptiveThresholdDescription() + " - " + getCurrentAThresholdDescription() + " - CVscope"
+func (f *adaptiveThresholdFilter) GoCode(src, dst string, channels int) string {
+	gray, code := grayGoCode(src, dst, channels)
+	return code + fmt.Sprintf("gocv.AdaptiveThreshold(%s, &%s, %1.f, gocv.%s, gocv.%s, %d, %1.f)",
+		gray, dst, 255.0, f.method.Description(), f.typ.Description(), f.blockSize.Pos(), f.cValue())
 }
 
-func adaptiveThresholdGoCodeFragment(mv float32, at string, t string, bs int, c float32) {
-	codeFragmentHeader("Go")
-	fmt.Printf("gocv.AdaptiveThreshold(src, &dest, %1.f, gocv.%s, gocv.%s, %d, %1.f)\n\n", mv, at, t, bs, c)
+func (f *adaptiveThresholdFilter) PythonCode(src, dst string, channels int) string {
+	gray, code := grayPythonCode(src, dst, channels)
+	return code + fmt.Sprintf("%s = cv.adaptiveThreshold(%s, %1.f, cv.%s, cv.%s, %d, %1.f)",
+		dst, gray, 255.0, f.method.OpenCV(), f.typ.OpenCV(), f.blockSize.Pos(), f.cValue())
 }
 
-func adaptiveThresholdPythonCodeFragment(mv float32, at string, t string, bs int, c float32) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *adaptiveThresholdFilter) CppCode(src, dst string, channels int) string {
+	gray, code := grayCppCode(src, dst, channels)
+	return code + fmt.Sprintf("cv::adaptiveThreshold(%s, %s, %1.f, %s, %s, %d, %1.f);",
+		gray, dst, 255.0, f.method.Cpp(), f.typ.Cpp(), f.blockSize.Pos(), f.cValue())
 }
@@ -4,103 +4,64 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(bilateralFilterCmd)
+	registerFilter(bilateralFilterCmd, newBilateralFilter)
 }
 
 var bilateralFilterCmd = &cobra.Command{
 	Use:   "bilateral",
 	Short: "Apply bilateral filter to video images",
-	Long: `Apply bilateral filter to video images.
+	Long:  `Apply bilateral filter to video images.`,
+}
 
-Key commands:
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleBilateralFilterCmd()
-	},
+type bilateralFilter struct {
+	diameter, sigmaColor, sigmaSpace *Param
 }
 
-func handleBilateralFilterCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
+func newBilateralFilter() Filter {
+	return &bilateralFilter{
+		diameter:   newParam("diameter", 1, 5, 2),
+		sigmaColor: newParam("sigma color", 0, 255, 60),
+		sigmaSpace: newParam("sigma space", 0, 255, 0),
 	}
-	defer video.Close()
-
-	window = gocv.NewWindow(bilateralFilterWindowTitle())
-	defer window.Close()
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	diameter := window.CreateTrackbar("diameter", 5)
-	diameter.SetMin(1)
-	diameter.SetPos(2)
-
-	sigmaColor := window.CreateTrackbar("sigma color", 255)
-	sigmaColor.SetPos(60)
+}
 
-	sigmaSpace := window.CreateTrackbar("sigma space", 255)
-	sigmaSpace.SetPos(0)
+func (f *bilateralFilter) Name() string {
+	return "bilateral"
+}
 
-	fmt.Printf("Start reading video: %v\n", videoSource)
+func (f *bilateralFilter) Title() string {
+	return "BilateralFilter - CVscope"
+}
 
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
+func (f *bilateralFilter) Params() []*Param {
+	return []*Param{f.diameter, f.sigmaColor, f.sigmaSpace}
+}
 
-		// BilateralFilter image processing filter
-		gocv.BilateralFilter(img, &processed, diameter.GetPos(), float64(sigmaColor.GetPos()), float64(sigmaSpace.GetPos()))
+func (f *bilateralFilter) Enums() []*Enum {
+	return nil
+}
 
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
+func (f *bilateralFilter) Validate() {}
 
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case gKey:
-			bilateralFilterGoCodeFragment(diameter.GetPos(), float64(sigmaColor.GetPos()), float64(sigmaSpace.GetPos()))
-		case pKey:
-			bilateralFilterPythonCodeFragment(diameter.GetPos(), float64(sigmaColor.GetPos()), float64(sigmaSpace.GetPos()))
-		case space:
-			handlePause(bilateralFilterWindowTitle())
-		case wKey:
-			writeFile("bilateral", processed)
-		case esc:
-			return
-		}
-	}
+func (f *bilateralFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.BilateralFilter(src, dst, f.diameter.Pos(), float64(f.sigmaColor.Pos()), float64(f.sigmaSpace.Pos()))
 }
 
-func bilateralFilterWindowTitle() string {
-	return "BilateralFilter - CVscope"
+func (f *bilateralFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.BilateralFilter(%s, &%s, %d, %1.f, %1.f)",
+		src, dst, f.diameter.Pos(), float64(f.sigmaColor.Pos()), float64(f.sigmaSpace.Pos()))
 }
 
-func bilateralFilterGoCodeFragment(d int, sC, sS float64) {
-	codeFragmentHeader("Go")
-	fmt.Printf("gocv.BilateralFilter(src, &dest, %d, %1.f, %1.f)\n\n", d, sC, sS)
+func (f *bilateralFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.bilateralFilter(%s, %d, %1.f, %1.f)",
+		dst, src, f.diameter.Pos(), float64(f.sigmaColor.Pos()), float64(f.sigmaSpace.Pos()))
 }
 
-func bilateralFilterPythonCodeFragment(d int, sC, sS float64) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *bilateralFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::bilateralFilter(%s, %s, %d, %1.f, %1.f);",
+		src, dst, f.diameter.Pos(), float64(f.sigmaColor.Pos()), float64(f.sigmaSpace.Pos()))
 }
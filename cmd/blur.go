@@ -5,101 +5,60 @@ import (
 	"image"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(blurCmd)
+	registerFilter(blurCmd, newBlurFilter)
 }
 
 var blurCmd = &cobra.Command{
 	Use:   "blur",
 	Short: "Blur video images",
-	Long: `Blur video images using a normalized box filter.
+	Long:  `Blur video images using a normalized box filter.`,
+}
 
-Key commands:
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleBlurCmd()
-	},
+type blurFilter struct {
+	ksizeX, ksizeY *Param
 }
 
-func handleBlurCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
+func newBlurFilter() Filter {
+	return &blurFilter{
+		ksizeX: newParam("ksize X", 1, 25, 12),
+		ksizeY: newParam("ksize Y", 1, 25, 12),
 	}
-	defer video.Close()
-
-	window = gocv.NewWindow(blurWindowTitle())
-	defer window.Close()
-
-	trackerX := window.CreateTrackbar("ksize X", 25)
-	trackerX.SetMin(1)
-	trackerX.SetPos(12)
-
-	trackerY := window.CreateTrackbar("ksize Y", 25)
-	trackerY.SetMin(1)
-	trackerY.SetPos(12)
-
-	img := gocv.NewMat()
-	defer img.Close()
+}
 
-	processed := gocv.NewMat()
-	defer processed.Close()
+func (f *blurFilter) Name() string {
+	return "blur"
+}
 
-	fmt.Printf("Start reading video: %v\n", videoSource)
+func (f *blurFilter) Title() string {
+	return "Blur - CVscope"
+}
 
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
+func (f *blurFilter) Params() []*Param {
+	return []*Param{f.ksizeX, f.ksizeY}
+}
 
-		// Blur image proccessing filter
-		gocv.Blur(img, &processed, image.Pt(trackerX.GetPos(), trackerY.GetPos()))
+func (f *blurFilter) Enums() []*Enum {
+	return nil
+}
 
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
+func (f *blurFilter) Validate() {}
 
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case gKey:
-			blurGoCodeFragment(trackerX.GetPos(), trackerY.GetPos())
-		case pKey:
-			blurPythonCodeFragment(trackerX.GetPos(), trackerY.GetPos())
-		case space:
-			handlePause(blurWindowTitle())
-		case wKey:
-			writeFile("blur", processed)
-		case esc:
-			return
-		}
-	}
+func (f *blurFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.Blur(src, dst, image.Pt(f.ksizeX.Pos(), f.ksizeY.Pos()))
 }
 
-func blurWindowTitle() string {
-	return "Blur - CVscope"
+func (f *blurFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.Blur(%s, &%s, image.Pt(%d, %d))", src, dst, f.ksizeX.Pos(), f.ksizeY.Pos())
 }
 
-func blurGoCodeFragment(x, y int) {
-	codeFragmentHeader("Go")
-	fmt.Printf("gocv.Blur(src, &dest, image.Pt(%d, %d))\n\n", x, y)
+func (f *blurFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.blur(%s, (%d, %d))", dst, src, f.ksizeX.Pos(), f.ksizeY.Pos())
 }
 
-func blurPythonCodeFragment(x, y int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *blurFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::blur(%s, %s, cv::Size(%d, %d));", src, dst, f.ksizeX.Pos(), f.ksizeY.Pos())
 }
@@ -0,0 +1,17 @@
+//go:build !customenv
+// +build !customenv
+
+package cmd
+
+// The same flags as GoCV are used to build the calls to OpenCV that GoCV does
+// not have, so that they use the same OpenCV. Use the customenv build tag to
+// set them using the CGO_CPPFLAGS, CGO_CXXFLAGS, and CGO_LDFLAGS environment
+// variables instead, as for GoCV.
+
+/*
+#cgo !windows pkg-config: opencv4
+#cgo CXXFLAGS:   --std=c++11
+#cgo windows  CPPFLAGS:   -IC:/opencv/build/install/include
+#cgo windows  LDFLAGS:    -LC:/opencv/build/install/x64/mingw/lib -lopencv_core455 -lopencv_highgui455
+*/
+import "C"
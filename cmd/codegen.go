@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// bgrChannels is the number of channels of the images from the video sources,
+// which is used for the input to the generated code.
+const bgrChannels = 3
+
+// channelFilter is implemented by filters whose output does not have the same
+// number of channels as their input, such as canny, so that the generated code
+// for the next filter knows what its input is.
+type channelFilter interface {
+	outputChannels(input int) int
+}
+
+// outputChannels returns the number of channels of the output of the filter,
+// given the number of channels of its input.
+func outputChannels(f Filter, input int) int {
+	if c, ok := f.(channelFilter); ok {
+		return c.outputChannels(input)
+	}
+	return input
+}
+
+// grayGoCode is the Go code that converts the src image to grayscale, for the
+// filters that only work on grayscale images. It returns the name of the
+// variable for the grayscale image along with the code, which is empty when
+// the src image is already grayscale.
+func grayGoCode(src, dst string, channels int) (gray, code string) {
+	if channels == 1 {
+		return src, ""
+	}
+
+	gray = dst + "Gray"
+	code = fmt.Sprintf("%s := gocv.NewMat()\ndefer %s.Close()\ngocv.CvtColor(%s, &%s, gocv.ColorBGRToGray)\n",
+		gray, gray, src, gray)
+	return
+}
+
+// bgrGoCode is the Go code that converts the src image to BGR, for the filters
+// that only work on color images, when the src image is the output of a filter
+// such as canny. It returns the name of the variable for the BGR image along
+// with the code, which is empty when the src image is already BGR.
+func bgrGoCode(src, dst string, channels int) (bgr, code string) {
+	conversion := "ColorGrayToBGR"
+	switch channels {
+	case bgrChannels:
+		return src, ""
+	case 4:
+		conversion = "ColorBGRAToBGR"
+	}
+
+	bgr = dst + "BGR"
+	code = fmt.Sprintf("%s := gocv.NewMat()\ndefer %s.Close()\ngocv.CvtColor(%s, &%s, gocv.%s)\n",
+		bgr, bgr, src, bgr, conversion)
+	return
+}
+
+// kernelGoCode is the Go code that creates the structuring element for the
+// morphology filters. It returns the name of the variable for the kernel
+// along with the code.
+func kernelGoCode(dst string, shape *Enum, x, y int) (kernel, code string) {
+	kernel = dst + "Kernel"
+	code = fmt.Sprintf("%s := gocv.GetStructuringElement(gocv.%s, image.Pt(%d, %d))\ndefer %s.Close()\n",
+		kernel, shape.Description(), x, y, kernel)
+	return
+}
+
+// grayPythonCode is the Python code that converts the src image to grayscale.
+// It returns the name of the variable for the grayscale image along with the code.
+func grayPythonCode(src, dst string, channels int) (gray, code string) {
+	if channels == 1 {
+		return src, ""
+	}
+
+	gray = dst + "_gray"
+	code = fmt.Sprintf("%s = cv.cvtColor(%s, cv.COLOR_BGR2GRAY)\n", gray, src)
+	return
+}
+
+// bgrPythonCode is the Python code that converts the src image to BGR.
+// It returns the name of the variable for the BGR image along with the code.
+func bgrPythonCode(src, dst string, channels int) (bgr, code string) {
+	conversion := "COLOR_GRAY2BGR"
+	switch channels {
+	case bgrChannels:
+		return src, ""
+	case 4:
+		conversion = "COLOR_BGRA2BGR"
+	}
+
+	bgr = dst + "_bgr"
+	code = fmt.Sprintf("%s = cv.cvtColor(%s, cv.%s)\n", bgr, src, conversion)
+	return
+}
+
+// kernelPythonCode is the Python code that creates the structuring element for
+// the morphology filters. It returns the name of the variable for the kernel
+// along with the code.
+func kernelPythonCode(dst string, shape *Enum, x, y int) (kernel, code string) {
+	kernel = dst + "_kernel"
+	code = fmt.Sprintf("%s = cv.getStructuringElement(cv.%s, (%d, %d))\n", kernel, shape.OpenCV(), x, y)
+	return
+}
+
+// grayCppCode is the C++ code that converts the src image to grayscale.
+// It returns the name of the variable for the grayscale image along with the code.
+func grayCppCode(src, dst string, channels int) (gray, code string) {
+	if channels == 1 {
+		return src, ""
+	}
+
+	gray = dst + "Gray"
+	code = fmt.Sprintf("cv::Mat %s;\ncv::cvtColor(%s, %s, cv::COLOR_BGR2GRAY);\n", gray, src, gray)
+	return
+}
+
+// bgrCppCode is the C++ code that converts the src image to BGR.
+// It returns the name of the variable for the BGR image along with the code.
+func bgrCppCode(src, dst string, channels int) (bgr, code string) {
+	conversion := "COLOR_GRAY2BGR"
+	switch channels {
+	case bgrChannels:
+		return src, ""
+	case 4:
+		conversion = "COLOR_BGRA2BGR"
+	}
+
+	bgr = dst + "BGR"
+	code = fmt.Sprintf("cv::Mat %s;\ncv::cvtColor(%s, %s, cv::%s);\n", bgr, src, bgr, conversion)
+	return
+}
+
+// kernelCppCode is the C++ code that creates the structuring element for the
+// morphology filters. It returns the name of the variable for the kernel along
+// with the code.
+func kernelCppCode(dst string, shape *Enum, x, y int) (kernel, code string) {
+	kernel = dst + "Kernel"
+	code = fmt.Sprintf("cv::Mat %s = cv::getStructuringElement(%s, cv::Size(%d, %d));\n", kernel, shape.Cpp(), x, y)
+	return
+}
+
+// goImports returns the packages that are needed by some Go code, along with
+// the ones that are always needed.
+func goImports(code string, always ...string) []string {
+	imports := append([]string{"gocv.io/x/gocv"}, always...)
+	if strings.Contains(code, "image.Pt(") {
+		imports = append(imports, "image")
+	}
+	if strings.Contains(code, "contrib.") {
+		imports = append(imports, "gocv.io/x/gocv/contrib")
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func goImportBlock(imports []string) string {
+	var std, other []string
+	for _, i := range imports {
+		if strings.Contains(i, ".") {
+			other = append(other, "\t\""+i+"\"")
+		} else {
+			std = append(std, "\t\""+i+"\"")
+		}
+	}
+
+	block := strings.Join(std, "\n")
+	if len(std) > 0 && len(other) > 0 {
+		block += "\n\n"
+	}
+	return "import (\n" + block + strings.Join(other, "\n") + "\n)\n"
+}
+
+// indent adds a tab to the start of every line of code that is not empty.
+func indent(code string) string {
+	lines := strings.Split(code, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = "\t" + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goApplyFunc is a reusable Go function that applies the filter with the
+// current settings, and returns the processed image which must be closed by
+// the caller.
+func goApplyFunc(f Filter) string {
+	return fmt.Sprintf(`// Apply applies the %s filter to the src image, using the settings from CVscope.
+// The caller must Close the returned image.
+func Apply(src gocv.Mat) gocv.Mat {
+	dest := gocv.NewMat()
+
+%s
+
+	return dest
+}
+`, f.Name(), indent(f.GoCode("src", "dest", bgrChannels)))
+}
+
+// goFuncCode is the Go code for the Apply function along with the imports it needs.
+func goFuncCode(f Filter) string {
+	apply := goApplyFunc(f)
+	return goImportBlock(goImports(apply)) + "\n" + apply
+}
+
+// goProgramCode is a complete Go program that displays video with the filter
+// applied using the current settings.
+func goProgramCode(f Filter) string {
+	apply := goApplyFunc(f)
+	return fmt.Sprintf(`// Program %s was generated by CVscope.
+//
+// How to run:
+//
+//	go mod tidy
+//	go run . [camera ID or video file]
+package main
+
+%s
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("How to run:\n\tgo run . [camera ID or video file]")
+		return
+	}
+
+	video, err := gocv.OpenVideoCapture(os.Args[1])
+	if err != nil {
+		fmt.Printf("Error opening video: %%v\n", err)
+		return
+	}
+	defer video.Close()
+
+	window := gocv.NewWindow(%q)
+	defer window.Close()
+
+	img := gocv.NewMat()
+	defer img.Close()
+
+	for {
+		if ok := video.Read(&img); !ok {
+			fmt.Printf("Device closed: %%v\n", os.Args[1])
+			return
+		}
+		if img.Empty() {
+			continue
+		}
+
+		dest := Apply(img)
+		window.IMShow(dest)
+		dest.Close()
+
+		if window.WaitKey(1) == 27 {
+			return
+		}
+	}
+}
+
+%s`, f.Name(), goImportBlock(goImports(apply, "fmt", "os")), f.Title(), apply)
+}
+
+// gocvVersion returns the version of GoCV that CVscope was built with, so that
+// generated programs are built with the same version.
+func gocvVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == "gocv.io/x/gocv" {
+			return moduleVersion(dep)
+		}
+	}
+	return ""
+}
+
+// moduleVersion returns the version of a module dependency. A replacement that
+// is a local directory has no version, so the version that was required is
+// used instead.
+func moduleVersion(dep *debug.Module) string {
+	if dep.Replace != nil && dep.Replace.Version != "" {
+		return dep.Replace.Version
+	}
+	if dep.Version == "(devel)" {
+		return ""
+	}
+	return dep.Version
+}
+
+func goModFile(module string) string {
+	mod := fmt.Sprintf("module %s\n\ngo 1.13\n", module)
+	if v := gocvVersion(); v != "" {
+		mod += fmt.Sprintf("\nrequire gocv.io/x/gocv %s\n", v)
+	}
+	return mod
+}
+
+// modulePath is a module path that is safe to use for any Go version.
+var modulePath = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// moduleName is the name of the module for a Go program written into dir,
+// which is the name of the directory when it makes a valid module path, so
+// that the program can be written into the current directory using ".".
+func moduleName(f Filter, dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		if name := filepath.Base(abs); modulePath.MatchString(name) {
+			return name
+		}
+	}
+	return "cvscope-" + f.Name()
+}
+
+// writeGoProgram writes a complete Go program for the filter into dir, along
+// with a go.mod file that uses the same version of GoCV as CVscope. There is no
+// go.sum file, so go mod tidy has to be run before the program is built.
+func writeGoProgram(f Filter, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(goProgramCode(f)), 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(goModFile(moduleName(f, dir))), 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote Go program: %s\n", filepath.Join(dir, "main.go"))
+	fmt.Fprintf(os.Stderr, "Run 'go mod tidy' in %s before running it\n", dir)
+	return nil
+}
+
+// writeCode writes the code for the filter to the output file, or to stdout
+// if there is no output file. For a complete Go program, the output is the
+// directory for the program.
+func writeCode(f Filter) error {
+	var code string
+	switch codeFormat {
+	case "go":
+		code = f.GoCode("src", "dest", bgrChannels) + "\n"
+	case "go-func":
+		code = goFuncCode(f)
+	case "go-program":
+		if outputFile != "" && outputFile != "-" {
+			return writeGoProgram(f, outputFile)
+		}
+		code = goProgramCode(f)
+	case "python":
+		code = f.PythonCode("src", "dest", bgrChannels) + "\n"
+	case "cpp":
+		code = f.CppCode("src", "dest", bgrChannels) + "\n"
+	default:
+		return fmt.Errorf("unknown code format %s, must be one of go, go-func, go-program, python, cpp", codeFormat)
+	}
+
+	if outputFile == "" || outputFile == "-" {
+		fmt.Print(code)
+		return nil
+	}
+	return ioutil.WriteFile(outputFile, []byte(code), 0644)
+}
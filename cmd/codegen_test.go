@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestModuleVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		dep  *debug.Module
+		want string
+	}{
+		{"required", &debug.Module{Path: "gocv.io/x/gocv", Version: "v0.30.0"}, "v0.30.0"},
+		{"replaced", &debug.Module{Path: "gocv.io/x/gocv", Version: "v0.30.0",
+			Replace: &debug.Module{Path: "github.com/fork/gocv", Version: "v0.30.1"}}, "v0.30.1"},
+		{"local replace", &debug.Module{Path: "gocv.io/x/gocv", Version: "v0.30.0",
+			Replace: &debug.Module{Path: "../gocv"}}, "v0.30.0"},
+		{"devel", &debug.Module{Path: "gocv.io/x/gocv", Version: "(devel)"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := moduleVersion(tt.dep); got != tt.want {
+			t.Errorf("%s: moduleVersion() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGrayCode(t *testing.T) {
+	tests := []struct {
+		lang string
+		code func(src, dst string, channels int) (string, string)
+		want string
+	}{
+		{"Go", grayGoCode, "gocv.ColorBGRToGray"},
+		{"Python", grayPythonCode, "cv.COLOR_BGR2GRAY"},
+		{"C++", grayCppCode, "cv::COLOR_BGR2GRAY"},
+	}
+
+	for _, tt := range tests {
+		gray, code := tt.code("src", "dest", 3)
+		if gray == "src" || !strings.Contains(code, tt.want) {
+			t.Errorf("%s: BGR input was not converted: %q", tt.lang, code)
+		}
+
+		gray, code = tt.code("src", "dest", 1)
+		if gray != "src" || code != "" {
+			t.Errorf("%s: gray input was converted: %q, %q", tt.lang, gray, code)
+		}
+	}
+}
+
+func TestBGRCode(t *testing.T) {
+	tests := []struct {
+		lang       string
+		code       func(src, dst string, channels int) (string, string)
+		gray, bgra string
+	}{
+		{"Go", bgrGoCode, "gocv.ColorGrayToBGR", "gocv.ColorBGRAToBGR"},
+		{"Python", bgrPythonCode, "cv.COLOR_GRAY2BGR", "cv.COLOR_BGRA2BGR"},
+		{"C++", bgrCppCode, "cv::COLOR_GRAY2BGR", "cv::COLOR_BGRA2BGR"},
+	}
+
+	for _, tt := range tests {
+		bgr, code := tt.code("src", "dest", 1)
+		if bgr == "src" || !strings.Contains(code, tt.gray) {
+			t.Errorf("%s: gray input was not converted: %q", tt.lang, code)
+		}
+
+		bgr, code = tt.code("src", "dest", 4)
+		if bgr == "src" || !strings.Contains(code, tt.bgra) {
+			t.Errorf("%s: BGRA input was not converted: %q", tt.lang, code)
+		}
+
+		bgr, code = tt.code("src", "dest", 3)
+		if bgr != "src" || code != "" {
+			t.Errorf("%s: BGR input was converted: %q, %q", tt.lang, bgr, code)
+		}
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir, want string
+	}{
+		{"cvscope-blur", "cvscope-blur"},
+		{"programs/edges", "edges"},
+		{".", filepath.Base(wd)},
+		{"/", "cvscope-blur"},
+		{"my program", "cvscope-blur"},
+	}
+
+	f := newBlurFilter()
+	for _, tt := range tests {
+		if got := moduleName(f, tt.dir); got != tt.want {
+			t.Errorf("moduleName(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+// eachSetting calls check with every registered filter, first with its default
+// settings and then with each option of each of its enums.
+func eachSetting(check func(name string, f Filter)) {
+	var names []string
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := filters[name]()
+		check(name, f)
+		for _, e := range f.Enums() {
+			for range e.Options {
+				e.Next()
+				f.Validate()
+				check(name+" "+e.Name+" "+e.Description(), f)
+			}
+		}
+	}
+}
+
+func parseGoProgram(t *testing.T, fset *token.FileSet, name string, f Filter) *ast.File {
+	file, err := parser.ParseFile(fset, "main.go", goProgramCode(f), parser.AllErrors)
+	if err != nil {
+		t.Errorf("%s: Go program does not parse: %v\n%s", name, err, goProgramCode(f))
+		return nil
+	}
+	return file
+}
+
+func TestGoProgramParses(t *testing.T) {
+	eachSetting(func(name string, f Filter) {
+		parseGoProgram(t, token.NewFileSet(), name, f)
+	})
+}
+
+// TestGoProgramCompiles type checks the Go programs against the source of the
+// version of GoCV that CVscope is built with.
+func TestGoProgramCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("type checking GoCV from source is slow")
+	}
+
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	eachSetting(func(name string, f Filter) {
+		if file := parseGoProgram(t, fset, name, f); file != nil {
+			if _, err := conf.Check("main", fset, []*ast.File{file}, nil); err != nil {
+				t.Errorf("%s: Go program does not compile: %v\n%s", name, err, goProgramCode(f))
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"image"
+	"image/color"
+
+	"gocv.io/x/gocv"
+)
+
+// viewMode is how the original and processed images are displayed.
+type viewMode int
+
+const (
+	// viewProcessed displays only the processed image.
+	viewProcessed viewMode = iota
+
+	// viewSideBySide displays the original and processed images next to each other.
+	viewSideBySide
+
+	// viewSplit displays the original image to the left of the split line, and
+	// the processed image to the right of it.
+	viewSplit
+
+	viewModes
+)
+
+func (v viewMode) String() string {
+	switch v {
+	case viewSideBySide:
+		return "side by side"
+	case viewSplit:
+		return "split"
+	}
+	return "processed"
+}
+
+var splitColor = color.RGBA{255, 255, 0, 0}
+
+// toBGR converts an image to 8-bit BGR so that it can be combined with other
+// images, such as the single channel or CV16S results of some filters.
+func toBGR(src gocv.Mat, dst *gocv.Mat) {
+	img := src
+	if depth(src) != gocv.MatTypeCV8U {
+		img = gocv.NewMat()
+		defer img.Close()
+		gocv.ConvertScaleAbs(src, &img, 1, 0)
+	}
+
+	switch img.Channels() {
+	case 1:
+		gocv.CvtColor(img, dst, gocv.ColorGrayToBGR)
+	case 4:
+		gocv.CvtColor(img, dst, gocv.ColorBGRAToBGR)
+	default:
+		img.CopyTo(dst)
+	}
+}
+
+// compare combines the original and processed images into dst using the view
+// mode. For the split view, split is the position of the split line as a
+// percentage of the width of the image.
+func compare(mode viewMode, original, processed gocv.Mat, split int, dst *gocv.Mat) {
+	left := gocv.NewMat()
+	defer left.Close()
+	toBGR(original, &left)
+
+	right := gocv.NewMat()
+	defer right.Close()
+	toBGR(processed, &right)
+
+	if mode == viewSideBySide {
+		gocv.Hconcat(left, right, dst)
+		return
+	}
+
+	left.CopyTo(dst)
+	x := left.Cols() * split / 100
+	rect := image.Rect(x, 0, left.Cols(), left.Rows())
+
+	from := right.Region(rect)
+	defer from.Close()
+	to := dst.Region(rect)
+	defer to.Close()
+	from.CopyTo(&to)
+
+	gocv.Line(dst, image.Pt(x, 0), image.Pt(x, left.Rows()), splitColor, 2)
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configKey returns the name used for a parameter or enum in config files and flags.
+func configKey(name string) string {
+	return strings.ToLower(strings.Replace(name, " ", "-", -1))
+}
+
+// setParam sets the position of a parameter, making sure it is within range.
+func setParam(p *Param, pos int) error {
+	if pos < p.Min || pos > p.Max {
+		return fmt.Errorf("%s must be between %d and %d, got %d", configKey(p.Name), p.Min, p.Max, pos)
+	}
+	p.SetPos(pos)
+	return nil
+}
+
+// setEnum sets the current choice of an enum using the name of the choice.
+func setEnum(e *Enum, name string) error {
+	for i, o := range e.Options {
+		if strings.EqualFold(o.Name, name) {
+			e.current = i
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %s", configKey(e.Name), enumNames(e), name)
+}
+
+func enumNames(e *Enum) string {
+	var names []string
+	for _, o := range e.Options {
+		names = append(names, o.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// addFilterFlags adds a flag to the command for every parameter and enum of the filter.
+func addFilterFlags(cmd *cobra.Command, f Filter) {
+	for _, p := range f.Params() {
+		cmd.Flags().Int(configKey(p.Name), p.Pos(), fmt.Sprintf("starting value for %s, from %d to %d", p.Name, p.Min, p.Max))
+	}
+	for _, e := range f.Enums() {
+		cmd.Flags().String(configKey(e.Name), e.Description(), fmt.Sprintf("starting %s, one of %s", e.Name, enumNames(e)))
+	}
+}
+
+// configureFilter sets the parameters of a filter using any values in the
+// config file, then the preset if one was chosen, and then any flags that were
+// set on the command line. The values for each filter are kept in the config
+// file under the name of the filter, and presets under the presets key, for example:
+//
+//	gaussian:
+//	  ksize-x: 5
+//	  border: BorderReflect
+//	presets:
+//	  gaussian:
+//	    smooth:
+//	      ksize-x: 9
+//	      border: BorderReplicate
+func configureFilter(cmd *cobra.Command, f Filter) error {
+	sections := []string{f.Name()}
+	if presetName != "" {
+		preset := presetKey(f, presetName)
+		switch {
+		case viper.IsSet(preset):
+			sections = append(sections, preset)
+		case headless:
+			return fmt.Errorf("preset %s not found for %s", presetName, f.Name())
+		default:
+			fmt.Printf("Preset %s not found for %s, press 'k' to create it.\n", presetName, f.Name())
+		}
+	}
+
+	set := map[*Param]int{}
+
+	for _, p := range f.Params() {
+		key := configKey(p.Name)
+		pos, ok := 0, false
+
+		for _, section := range sections {
+			if viper.IsSet(section + "." + key) {
+				pos, ok = viper.GetInt(section+"."+key), true
+			}
+		}
+		if cmd.Flags().Changed(key) {
+			pos, _ = cmd.Flags().GetInt(key)
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setParam(p, pos); err != nil {
+			return err
+		}
+		set[p] = pos
+	}
+
+	for _, e := range f.Enums() {
+		key := configKey(e.Name)
+		name, ok := "", false
+
+		for _, section := range sections {
+			if viper.IsSet(section + "." + key) {
+				name, ok = viper.GetString(section+"."+key), true
+			}
+		}
+		if cmd.Flags().Changed(key) {
+			name, _ = cmd.Flags().GetString(key)
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setEnum(e, name); err != nil {
+			return err
+		}
+	}
+
+	return validateFilter(f, set)
+}
+
+// validateFilter applies the same rules used for the trackbars, and returns an
+// error if any of the values that were explicitly set would have been changed.
+func validateFilter(f Filter, set map[*Param]int) error {
+	f.Validate()
+
+	for _, p := range f.Params() {
+		if pos, ok := set[p]; ok && p.Pos() != pos {
+			return fmt.Errorf("invalid value %d for %s, the closest valid value is %d", pos, configKey(p.Name), p.Pos())
+		}
+	}
+	return nil
+}
+
+func presetKey(f Filter, name string) string {
+	return "presets." + f.Name() + "." + name
+}
+
+// filterValues returns the current parameters and enum choices of the filter,
+// using the same keys as the config file.
+func filterValues(f Filter) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, p := range f.Params() {
+		values[configKey(p.Name)] = p.Pos()
+	}
+	for _, e := range f.Enums() {
+		values[configKey(e.Name)] = e.Description()
+	}
+	return values
+}
+
+// setFilterValues sets the parameters and enum choices of the filter from
+// values that use the same keys as the config file.
+func setFilterValues(f Filter, values map[string]interface{}) error {
+	set := map[*Param]int{}
+
+	for key, value := range values {
+		found := false
+
+		for _, p := range f.Params() {
+			if !strings.EqualFold(key, configKey(p.Name)) {
+				continue
+			}
+
+			pos, err := toInt(value)
+			if err != nil {
+				return fmt.Errorf("%s for %s: %v", key, f.Name(), err)
+			}
+			if err := setParam(p, pos); err != nil {
+				return err
+			}
+			set[p] = pos
+			found = true
+		}
+
+		for _, e := range f.Enums() {
+			if !strings.EqualFold(key, configKey(e.Name)) {
+				continue
+			}
+
+			if err := setEnum(e, fmt.Sprint(value)); err != nil {
+				return err
+			}
+			found = true
+		}
+
+		if !found {
+			return fmt.Errorf("unknown setting %s for %s", key, f.Name())
+		}
+	}
+
+	return validateFilter(f, set)
+}
+
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case string:
+		return strconv.Atoi(v)
+	}
+	return 0, fmt.Errorf("%v is not a whole number", value)
+}
+
+// savePreset saves the current parameters and enum choices of the filter to
+// the config file, using the name of the preset that was chosen at startup.
+func savePreset(f Filter) error {
+	name := presetName
+	if name == "" {
+		name = "default"
+	}
+
+	viper.Set(presetKey(f, name), filterValues(f))
+
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		file = filepath.Join(home, ".cvscope.yaml")
+	}
+
+	if err := viper.WriteConfigAs(file); err != nil {
+		return err
+	}
+	fmt.Printf("Saved preset %s for %s to %s\n", name, f.Name(), file)
+	return nil
+}
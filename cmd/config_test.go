@@ -0,0 +1,77 @@
+package cmd
+
+import "testing"
+
+func TestToInt(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{value: 5, want: 5},
+		{value: int64(7), want: 7},
+		{value: float64(9), want: 9},
+		{value: "11", want: 11},
+		{value: 1.5, wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: true, wantErr: true},
+		{value: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := toInt(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("toInt(%#v) error = %v", tt.value, err)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("toInt(%#v) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSetFilterValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "params and enum",
+			values: map[string]interface{}{"ksize": 5, "dx": 1, "border": "BorderReflect"},
+			want:   map[string]interface{}{"ksize": 5, "dx": 1, "border": "BorderReflect"},
+		},
+		{
+			name:   "YAML and JSON numbers",
+			values: map[string]interface{}{"ksize": float64(7), "scale": "10"},
+			want:   map[string]interface{}{"ksize": 7, "scale": 10},
+		},
+		{
+			name:   "case of keys and enum names",
+			values: map[string]interface{}{"KSIZE": 5, "border": "borderreplicate"},
+			want:   map[string]interface{}{"ksize": 5, "border": "BorderReplicate"},
+		},
+		{name: "unknown setting", values: map[string]interface{}{"sigma": 3}, wantErr: true},
+		{name: "out of range", values: map[string]interface{}{"ksize": 9}, wantErr: true},
+		{name: "even kernel size", values: map[string]interface{}{"ksize": 4}, wantErr: true},
+		{name: "not a whole number", values: map[string]interface{}{"ksize": 2.5}, wantErr: true},
+		{name: "unknown enum choice", values: map[string]interface{}{"border": "BorderWrap"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		f := newSobelFilter()
+		err := setFilterValues(f, tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: setFilterValues() error = %v", tt.name, err)
+			continue
+		}
+
+		got := filterValues(f)
+		for key, want := range tt.want {
+			if got[key] != want {
+				t.Errorf("%s: %s = %v, want %v", tt.name, key, got[key], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	registerFilter(cvtColorCmd, newCvtColorFilter)
+}
+
+var cvtColorCmd = &cobra.Command{
+	Use:   "cvtcolor",
+	Short: "Convert video images to another color space",
+	Long:  `Convert video images to another color space, and view each of the channels of the result.`,
+}
+
+// channelNames are the names of the channels for each of the color conversions.
+var channelNames = map[int][]string{
+	int(gocv.ColorBGRToGray):    {"Gray"},
+	int(gocv.ColorBGRToHSV):     {"H", "S", "V"},
+	int(gocv.ColorBGRToHSVFull): {"H", "S", "V"},
+	int(gocv.ColorBGRToHLS):     {"H", "L", "S"},
+	int(gocv.ColorBGRToLab):     {"L", "a", "b"},
+	int(gocv.ColorBGRToLuv):     {"L", "u", "v"},
+	int(gocv.ColorBGRToYCrCb):   {"Y", "Cr", "Cb"},
+	int(gocv.ColorBGRToYUV):     {"Y", "U", "V"},
+	int(gocv.ColorBGRToXYZ):     {"X", "Y", "Z"},
+	int(gocv.ColorBGRToRGB):     {"R", "G", "B"},
+}
+
+// colormapGray displays a single channel as grayscale instead of using a colormap.
+const colormapGray = -1
+
+type cvtColorFilter struct {
+	code, channel, colormap *Enum
+}
+
+func newCvtColorFilter() Filter {
+	return &cvtColorFilter{
+		code: &Enum{
+			Name:    "color conversion",
+			PrevKey: zKey,
+			NextKey: xKey,
+			Options: []Option{
+				{"ColorBGRToGray", int(gocv.ColorBGRToGray), "COLOR_BGR2GRAY"},
+				{"ColorBGRToHSV", int(gocv.ColorBGRToHSV), "COLOR_BGR2HSV"},
+				{"ColorBGRToHSVFull", int(gocv.ColorBGRToHSVFull), "COLOR_BGR2HSV_FULL"},
+				{"ColorBGRToHLS", int(gocv.ColorBGRToHLS), "COLOR_BGR2HLS"},
+				{"ColorBGRToLab", int(gocv.ColorBGRToLab), "COLOR_BGR2Lab"},
+				{"ColorBGRToLuv", int(gocv.ColorBGRToLuv), "COLOR_BGR2Luv"},
+				{"ColorBGRToYCrCb", int(gocv.ColorBGRToYCrCb), "COLOR_BGR2YCrCb"},
+				{"ColorBGRToYUV", int(gocv.ColorBGRToYUV), "COLOR_BGR2YUV"},
+				{"ColorBGRToXYZ", int(gocv.ColorBGRToXYZ), "COLOR_BGR2XYZ"},
+				{"ColorBGRToRGB", int(gocv.ColorBGRToRGB), "COLOR_BGR2RGB"},
+			},
+		},
+		channel: &Enum{
+			Name:    "channel",
+			PrevKey: aKey,
+			NextKey: sKey,
+			Options: []Option{
+				{"All", -1, ""},
+				{"1", 0, ""},
+				{"2", 1, ""},
+				{"3", 2, ""},
+			},
+		},
+		colormap: &Enum{
+			Name:    "channel display",
+			PrevKey: upperDKey,
+			NextKey: dKey,
+			Options: []Option{
+				{"Gray", colormapGray, ""},
+				{"ColormapJet", int(gocv.ColormapJet), "COLORMAP_JET"},
+				{"ColormapHsv", int(gocv.ColormapHsv), "COLORMAP_HSV"},
+				{"ColormapParula", int(gocv.ColormapParula), "COLORMAP_PARULA"},
+			},
+		},
+	}
+}
+
+func (f *cvtColorFilter) Name() string {
+	return "cvtcolor"
+}
+
+func (f *cvtColorFilter) Title() string {
+	title := "Color Conversion - " + f.code.Description()
+	if c := f.channelIndex(); c >= 0 {
+		title += fmt.Sprintf(" - channel %d (%s) - %s", c+1, channelNames[f.code.Value()][c], f.colormap.Description())
+	}
+	return title + " - CVscope"
+}
+
+func (f *cvtColorFilter) Params() []*Param {
+	return nil
+}
+
+func (f *cvtColorFilter) Enums() []*Enum {
+	return []*Enum{f.code, f.channel, f.colormap}
+}
+
+func (f *cvtColorFilter) Validate() {}
+
+// channelIndex returns the index of the channel that is shown, or -1 when all
+// of the channels are shown. The grayscale conversion only has a single
+// channel, so all of the channels are shown for it.
+func (f *cvtColorFilter) channelIndex() int {
+	c := f.channel.Value()
+	if c >= len(channelNames[f.code.Value()]) {
+		return -1
+	}
+	return c
+}
+
+func (f *cvtColorFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	// the conversions are all from BGR images
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	toBGR(src, &bgr)
+
+	c := f.channelIndex()
+	if c < 0 {
+		gocv.CvtColor(bgr, dst, gocv.ColorConversionCode(f.code.Value()))
+		return
+	}
+
+	converted := gocv.NewMat()
+	defer converted.Close()
+	gocv.CvtColor(bgr, &converted, gocv.ColorConversionCode(f.code.Value()))
+
+	channels := gocv.Split(converted)
+	defer func() {
+		for _, m := range channels {
+			m.Close()
+		}
+	}()
+	channels[c].CopyTo(dst)
+}
+
+// display renders a single channel using the colormap, which shows small
+// differences more clearly than grayscale.
+func (f *cvtColorFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	if f.channelIndex() < 0 || f.colormap.Value() == colormapGray {
+		processed.CopyTo(dst)
+		return
+	}
+	gocv.ApplyColorMap(processed, dst, gocv.ColormapTypes(f.colormap.Value()))
+}
+
+// outputChannels is a single channel when only one of the channels is shown,
+// or for the grayscale conversion.
+func (f *cvtColorFilter) outputChannels(input int) int {
+	if f.channelIndex() >= 0 || f.code.Value() == int(gocv.ColorBGRToGray) {
+		return 1
+	}
+	return bgrChannels
+}
+
+func (f *cvtColorFilter) GoCode(src, dst string, channels int) string {
+	bgr, code := bgrGoCode(src, dst, channels)
+	c := f.channelIndex()
+	if c < 0 {
+		return code + fmt.Sprintf("gocv.CvtColor(%s, &%s, gocv.%s)", bgr, dst, f.code.Description())
+	}
+
+	return code + fmt.Sprintf(`%[3]sConverted := gocv.NewMat()
+defer %[3]sConverted.Close()
+gocv.CvtColor(%[1]s, &%[3]sConverted, gocv.%[2]s)
+%[3]sChannels := gocv.Split(%[3]sConverted)
+%[3]sChannels[%[4]d].CopyTo(&%[3]s)
+for _, c := range %[3]sChannels {
+	c.Close()
+}`, bgr, f.code.Description(), dst, c)
+}
+
+func (f *cvtColorFilter) PythonCode(src, dst string, channels int) string {
+	bgr, code := bgrPythonCode(src, dst, channels)
+	c := f.channelIndex()
+	if c < 0 {
+		return code + fmt.Sprintf("%s = cv.cvtColor(%s, cv.%s)", dst, bgr, f.code.OpenCV())
+	}
+	return code + fmt.Sprintf("%s = cv.split(cv.cvtColor(%s, cv.%s))[%d]", dst, bgr, f.code.OpenCV(), c)
+}
+
+func (f *cvtColorFilter) CppCode(src, dst string, channels int) string {
+	bgr, code := bgrCppCode(src, dst, channels)
+	c := f.channelIndex()
+	if c < 0 {
+		return code + fmt.Sprintf("cv::cvtColor(%s, %s, %s);", bgr, dst, f.code.Cpp())
+	}
+
+	return code + fmt.Sprintf(`cv::Mat %[3]sConverted;
+cv::cvtColor(%[1]s, %[3]sConverted, %[2]s);
+std::vector<cv::Mat> %[3]sChannels;
+cv::split(%[3]sConverted, %[3]sChannels);
+%[3]s = %[3]sChannels[%[4]d];`, bgr, f.code.Cpp(), dst, c)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+// displayer is implemented by filters whose output has to be rendered before
+// it can be displayed, such as the CV16S output of the derivative filters.
+type displayer interface {
+	// display renders the processed image into dst, using the src image that
+	// was processed if needed.
+	display(src, processed gocv.Mat, dst *gocv.Mat)
+}
+
+// render renders the processed image for display. The output of filters that
+// are not displayers is only converted when it is not 8-bit.
+func render(f Filter, src, processed gocv.Mat, dst *gocv.Mat) {
+	if d, ok := f.(displayer); ok {
+		d.display(src, processed, dst)
+		return
+	}
+
+	if depth(processed) != gocv.MatTypeCV8U {
+		gocv.ConvertScaleAbs(processed, dst, 1, 0)
+		return
+	}
+	processed.CopyTo(dst)
+}
+
+// The ways that the output of the derivative filters can be displayed.
+const (
+	// displayAbs displays the absolute value of the derivative.
+	displayAbs = iota
+
+	// displaySigned displays negative values in blue and positive values in red.
+	displaySigned
+
+	// displayMagnitude displays the magnitude of the gradient, combining dx and dy.
+	displayMagnitude
+
+	// displayAngle displays the direction of the gradient as the hue, with the
+	// magnitude as the brightness.
+	displayAngle
+)
+
+// newDisplayEnum returns the enum for the display modes of the derivative
+// filters. The magnitude and angle modes are only included for the filters
+// that have a gradient.
+func newDisplayEnum(gradient bool) *Enum {
+	e := &Enum{
+		Name:    "display",
+		PrevKey: upperDKey,
+		NextKey: dKey,
+		Options: []Option{
+			{Name: "Abs", Value: displayAbs},
+			{Name: "Signed", Value: displaySigned},
+		},
+	}
+	if gradient {
+		e.Options = append(e.Options,
+			Option{Name: "Magnitude", Value: displayMagnitude},
+			Option{Name: "Angle", Value: displayAngle},
+		)
+	}
+	return e
+}
+
+// derivativeFunc computes a derivative of the gray image as CV32F, for the
+// magnitude and angle display modes.
+type derivativeFunc func(gray gocv.Mat, dx, dy int, dst *gocv.Mat)
+
+// displayDerivative renders the output of a derivative filter using the
+// current display mode.
+func displayDerivative(mode *Enum, src, processed gocv.Mat, dst *gocv.Mat, derivative derivativeFunc) {
+	switch mode.Value() {
+	case displaySigned:
+		signedMap(processed, dst)
+	case displayMagnitude, displayAngle:
+		gradient(mode.Value() == displayAngle, src, dst, derivative)
+	default:
+		gocv.ConvertScaleAbs(processed, dst, 1, 0)
+	}
+}
+
+// signedMap renders signed values using a diverging colormap, with negative
+// values in blue, positive values in red, and zero in black. The values are
+// scaled so that the largest one is at full brightness.
+func signedMap(processed gocv.Mat, dst *gocv.Mat) {
+	values := gocv.NewMat()
+	defer values.Close()
+	processed.ConvertTo(&values, gocv.MatTypeCV32F)
+	if values.Channels() > 1 {
+		gocv.CvtColor(values, &values, gocv.ColorBGRToGray)
+	}
+
+	minVal, maxVal, _, _ := gocv.MinMaxLoc(values)
+	limit := math.Max(-float64(minVal), float64(maxVal))
+	if limit == 0 {
+		limit = 1
+	}
+
+	positive := gocv.NewMat()
+	defer positive.Close()
+	gocv.Threshold(values, &positive, 0, 0, gocv.ThresholdToZero)
+
+	negative := gocv.NewMat()
+	defer negative.Close()
+	gocv.Threshold(values, &negative, 0, 0, gocv.ThresholdToZeroInv)
+
+	red := gocv.NewMat()
+	defer red.Close()
+	gocv.ConvertScaleAbs(positive, &red, 255/limit, 0)
+
+	blue := gocv.NewMat()
+	defer blue.Close()
+	gocv.ConvertScaleAbs(negative, &blue, 255/limit, 0)
+
+	green := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), values.Rows(), values.Cols(), gocv.MatTypeCV8U)
+	defer green.Close()
+
+	gocv.Merge([]gocv.Mat{blue, green, red}, dst)
+}
+
+// gradient renders the magnitude of the gradient of the src image, or its
+// angle when angle is set.
+func gradient(angle bool, src gocv.Mat, dst *gocv.Mat, derivative derivativeFunc) {
+	gray := gocv.NewMat()
+	defer gray.Close()
+	toGray(src, &gray)
+
+	dx := gocv.NewMat()
+	defer dx.Close()
+	derivative(gray, 1, 0, &dx)
+
+	dy := gocv.NewMat()
+	defer dy.Close()
+	derivative(gray, 0, 1, &dy)
+
+	magnitude := gocv.NewMat()
+	defer magnitude.Close()
+	gocv.Magnitude(dx, dy, &magnitude)
+	gocv.Normalize(magnitude, &magnitude, 0, 255, gocv.NormMinMax)
+
+	brightness := gocv.NewMat()
+	defer brightness.Close()
+	magnitude.ConvertTo(&brightness, gocv.MatTypeCV8U)
+
+	if !angle {
+		brightness.CopyTo(dst)
+		return
+	}
+
+	// the hue for 8-bit images ranges from 0 to 180
+	directions := gocv.NewMat()
+	defer directions.Close()
+	gocv.Phase(dx, dy, &directions, true)
+
+	hue := gocv.NewMat()
+	defer hue.Close()
+	directions.ConvertToWithParams(&hue, gocv.MatTypeCV8U, 0.5, 0)
+
+	saturation := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(255, 0, 0, 0), gray.Rows(), gray.Cols(), gocv.MatTypeCV8U)
+	defer saturation.Close()
+
+	hsv := gocv.NewMat()
+	defer hsv.Close()
+	gocv.Merge([]gocv.Mat{hue, saturation, brightness}, &hsv)
+	gocv.CvtColor(hsv, dst, gocv.ColorHSVToBGR)
+}
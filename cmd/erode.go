@@ -5,113 +5,68 @@ import (
 	"image"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(erodeCmd)
+	registerFilter(erodeCmd, newErodeFilter)
 }
 
-var currentErodeShape int
-
 var erodeCmd = &cobra.Command{
 	Use:   "erode",
 	Short: "Erode video images",
-	Long: `Erode video images.
-	
-Key commands:
-  Use 'z' and 'x' keys to page through structuring element shapes.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleErodeCmd()
-	},
+	Long:  `Erode video images.`,
 }
 
-func handleErodeCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(erodeWindowTitle())
-	defer window.Close()
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	trackerX := window.CreateTrackbar("ksize X", 25)
-	trackerX.SetMin(1)
-	trackerX.SetPos(12)
-
-	trackerY := window.CreateTrackbar("ksize Y", 25)
-	trackerY.SetMin(1)
-	trackerY.SetPos(12)
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// Erode image proccessing filter
-		kernel := gocv.GetStructuringElement(getCurrentMorphShape(currentErodeShape), image.Pt(trackerX.GetPos(), trackerY.GetPos()))
-		gocv.Erode(img, &processed, kernel)
-		kernel.Close()
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentErodeShape = prevShape(currentErodeShape)
-			window.SetWindowTitle(erodeWindowTitle())
-		case xKey:
-			currentErodeShape = nextShape(currentErodeShape)
-			window.SetWindowTitle(erodeWindowTitle())
-		case gKey:
-			erodeGoCodeFragment(getCurrentMorphShapeDescription(currentErodeShape), trackerX.GetPos(), trackerY.GetPos())
-		case pKey:
-			erodePythonCodeFragment(currentErodeShape, trackerX.GetPos(), trackerY.GetPos())
-		case space:
-			handlePause(erodeWindowTitle())
-		case wKey:
-			writeFile("erode", processed)
-		case esc:
-			return
-		}
+type erodeFilter struct {
+	ksizeX, ksizeY *Param
+	shape          *Enum
+}
+
+func newErodeFilter() Filter {
+	return &erodeFilter{
+		ksizeX: newParam("ksize X", 1, 25, 12),
+		ksizeY: newParam("ksize Y", 1, 25, 12),
+		shape:  newMorphShapeEnum(),
 	}
 }
 
-func erodeWindowTitle() string {
-	return "Erode - " + getCurrentMorphShapeDescription(currentErodeShape) + "- CVscope"
+func (f *erodeFilter) Name() string {
+	return "erode"
+}
+
+func (f *erodeFilter) Title() string {
+	return "Erode - " + f.shape.Description() + " - CVscope"
+}
+
+func (f *erodeFilter) Params() []*Param {
+	return []*Param{f.ksizeX, f.ksizeY}
+}
+
+func (f *erodeFilter) Enums() []*Enum {
+	return []*Enum{f.shape}
+}
+
+func (f *erodeFilter) Validate() {}
+
+func (f *erodeFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	kernel := gocv.GetStructuringElement(gocv.MorphShape(f.shape.Value()), image.Pt(f.ksizeX.Pos(), f.ksizeY.Pos()))
+	defer kernel.Close()
+
+	gocv.Erode(src, dst, kernel)
+}
+
+func (f *erodeFilter) GoCode(src, dst string, channels int) string {
+	kernel, code := kernelGoCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("gocv.Erode(%s, &%s, %s)", src, dst, kernel)
 }
 
-func erodeGoCodeFragment(morphType string, x, y int) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\nkernel := gocv.GetStructuringElement(gocv.%s, image.Pt(%d, %d))\n", morphType, x, y)
-	fmt.Printf("gocv.Erode(src, &dest, kernel)\n\n")
+func (f *erodeFilter) PythonCode(src, dst string, channels int) string {
+	kernel, code := kernelPythonCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("%s = cv.erode(%s, %s)", dst, src, kernel)
 }
 
-func erodePythonCodeFragment(morphType, x, y int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *erodeFilter) CppCode(src, dst string, channels int) string {
+	kernel, code := kernelCppCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("cv::erode(%s, %s, %s);", src, dst, kernel)
 }
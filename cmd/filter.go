@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gocv.io/x/gocv"
+)
+
+// Filter is an image processing operation that can be explored using CVscope.
+type Filter interface {
+	// Name is the name of the command for the filter, also used when writing files.
+	Name() string
+
+	// Title is the window title that describes the current filter settings.
+	Title() string
+
+	// Params are the numeric parameters that are controlled using trackbars.
+	Params() []*Param
+
+	// Enums are the enumerated settings that are paged through using keys.
+	Enums() []*Enum
+
+	// Validate makes sure that the current parameters do not have any invalid values.
+	Validate()
+
+	// Process applies the filter to the src image, with the result going into dst.
+	Process(src gocv.Mat, dst *gocv.Mat)
+
+	// GoCode is the Go code that implements the filter with the current settings,
+	// reading from the src variable, which has the number of channels given, and
+	// writing to the dst variable.
+	GoCode(src, dst string, channels int) string
+
+	// PythonCode is the Python code that implements the filter with the current settings,
+	// reading from the src variable, which has the number of channels given, and
+	// writing to the dst variable.
+	PythonCode(src, dst string, channels int) string
+
+	// CppCode is the C++ code that implements the filter with the current settings,
+	// reading from the src variable, which has the number of channels given, and
+	// writing to the dst variable.
+	CppCode(src, dst string, channels int) string
+}
+
+// Param is a numeric parameter for a filter.
+type Param struct {
+	Name    string
+	Label   string
+	Min     int
+	Max     int
+	pos     int
+	tracker *gocv.Trackbar
+}
+
+// newParam returns a Param with the given range and starting position.
+func newParam(name string, min, max, pos int) *Param {
+	return &Param{Name: name, Min: min, Max: max, pos: pos}
+}
+
+// Pos returns the current position of the parameter.
+func (p *Param) Pos() int {
+	if p.tracker != nil {
+		return p.tracker.GetPos()
+	}
+	return p.pos
+}
+
+// SetPos changes the current position of the parameter.
+func (p *Param) SetPos(pos int) {
+	p.pos = pos
+	if p.tracker != nil {
+		p.tracker.SetPos(pos)
+	}
+}
+
+// attach creates the trackbar that controls the parameter, using the label
+// for the trackbar name if there is one.
+func (p *Param) attach(window *gocv.Window) {
+	label := p.Name
+	if p.Label != "" {
+		label = p.Label
+	}
+
+	p.tracker = window.CreateTrackbar(label, p.Max)
+	if p.Min != 0 {
+		p.tracker.SetMin(p.Min)
+	}
+	p.tracker.SetPos(p.pos)
+}
+
+// Option is a single named choice for an Enum.
+type Option struct {
+	Name  string
+	Value int
+
+	// OpenCV is the name of the matching OpenCV constant, without the cv namespace.
+	OpenCV string
+}
+
+// Enum is a setting for a filter that has a fixed set of choices.
+type Enum struct {
+	Name    string
+	PrevKey int
+	NextKey int
+	Options []Option
+	current int
+}
+
+// Value returns the value of the current choice.
+func (e *Enum) Value() int {
+	return e.Options[e.current].Value
+}
+
+// Description returns the name of the current choice.
+func (e *Enum) Description() string {
+	return e.Options[e.current].Name
+}
+
+// OpenCV returns the name of the OpenCV constant for the current choice.
+func (e *Enum) OpenCV() string {
+	return e.Options[e.current].OpenCV
+}
+
+// Cpp returns the name of the OpenCV constant for the current choice, as used
+// in C++ code.
+func (e *Enum) Cpp() string {
+	return "cv::" + strings.ReplaceAll(e.OpenCV(), ".", "::")
+}
+
+// Prev pages to the previous choice.
+func (e *Enum) Prev() {
+	e.current--
+	if e.current < 0 {
+		e.current = len(e.Options) - 1
+	}
+}
+
+// Next pages to the next choice.
+func (e *Enum) Next() {
+	e.current = (e.current + 1) % len(e.Options)
+}
+
+// filters is the registry of all of the filters that CVscope knows about.
+var filters = map[string]func() Filter{}
+
+// registerFilter adds a filter to the registry, and adds the command that runs it
+// along with the flags for the parameters of the filter and the help for its keys.
+func registerFilter(cmd *cobra.Command, newFilter func() Filter) {
+	filters[cmd.Use] = newFilter
+	addFilterFlags(cmd, newFilter())
+	cmd.Long += keyCommands(newFilter())
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		f := newFilter()
+		if err := configureFilter(cmd, f); err != nil {
+			return err
+		}
+
+		if headless {
+			return runHeadless(f)
+		}
+		runFilter(f)
+		return nil
+	}
+	rootCmd.AddCommand(cmd)
+}
+
+// newFilter returns a new instance of a registered filter.
+func newFilter(name string) (Filter, error) {
+	f, ok := filters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter: %s", name)
+	}
+	return f(), nil
+}
+
+func newBorderEnum() *Enum {
+	return &Enum{
+		Name:    "border",
+		PrevKey: zKey,
+		NextKey: xKey,
+		Options: []Option{
+			{"BorderConstant", int(gocv.BorderConstant), "BORDER_CONSTANT"},
+			{"BorderReplicate", int(gocv.BorderReplicate), "BORDER_REPLICATE"},
+			{"BorderReflect", int(gocv.BorderReflect), "BORDER_REFLECT"},
+			{"BorderReflect101", int(gocv.BorderReflect101), "BORDER_REFLECT_101"},
+		},
+	}
+}
+
+func newMorphShapeEnum() *Enum {
+	return &Enum{
+		Name:    "morph shape",
+		PrevKey: zKey,
+		NextKey: xKey,
+		Options: []Option{
+			{"MorphRect", int(gocv.MorphRect), "MORPH_RECT"},
+			{"MorphCross", int(gocv.MorphCross), "MORPH_CROSS"},
+			{"MorphEllipse", int(gocv.MorphEllipse), "MORPH_ELLIPSE"},
+		},
+	}
+}
+
+func newThresholdEnum(prevKey, nextKey int, options int) *Enum {
+	e := &Enum{
+		Name:    "threshold type",
+		PrevKey: prevKey,
+		NextKey: nextKey,
+		Options: []Option{
+			{"ThresholdBinary", int(gocv.ThresholdBinary), "THRESH_BINARY"},
+			{"ThresholdBinaryInv", int(gocv.ThresholdBinaryInv), "THRESH_BINARY_INV"},
+			{"ThresholdTrunc", int(gocv.ThresholdTrunc), "THRESH_TRUNC"},
+			{"ThresholdToZero", int(gocv.ThresholdToZero), "THRESH_TOZERO"},
+			{"ThresholdToZeroInv", int(gocv.ThresholdToZeroInv), "THRESH_TOZERO_INV"},
+		},
+	}
+	e.Options = e.Options[:options]
+	return e
+}
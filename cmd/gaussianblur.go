@@ -5,138 +5,92 @@ import (
 	"image"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(gaussianBlurCmd)
+	registerFilter(gaussianBlurCmd, newGaussianBlurFilter)
 }
 
-var currentGaussianBlurBorder int
-var gaussianKsizeX, gaussianKsizeY, gaussianSigmaX, gaussianSigmaY *gocv.Trackbar
-var gaussianKX, gaussianKY int
-var gaussianSX, gaussianSY float64
-
 var gaussianBlurCmd = &cobra.Command{
 	Use:   "gaussian",
 	Short: "Apply Gaussian blur to video images",
-	Long: `Apply Gaussian blur to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through border calculation types.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleGaussianBlurCmd()
-	},
+	Long:  `Apply Gaussian blur to video images.`,
 }
 
-func handleGaussianBlurCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(gaussianBlurWindowTitle())
-	defer window.Close()
-
-	gaussianKsizeX = window.CreateTrackbar("ksize X", 25)
-	gaussianKsizeX.SetPos(0)
-
-	gaussianKsizeY = window.CreateTrackbar("ksize Y", 25)
-	gaussianKsizeY.SetPos(0)
-
-	gaussianSigmaX = window.CreateTrackbar("sigma X", 60)
-	gaussianSigmaX.SetPos(30)
-
-	gaussianSigmaY = window.CreateTrackbar("sigma Y", 60)
-	gaussianSigmaY.SetPos(0)
-
-	img := gocv.NewMat()
-	defer img.Close()
+type gaussianBlurFilter struct {
+	ksizeX, ksizeY, sigmaX, sigmaY *Param
+	border                         *Enum
+}
 
-	processed := gocv.NewMat()
-	defer processed.Close()
+func newGaussianBlurFilter() Filter {
+	return &gaussianBlurFilter{
+		ksizeX: newParam("ksize X", 0, 25, 0),
+		ksizeY: newParam("ksize Y", 0, 25, 0),
+		sigmaX: newParam("sigma X", 0, 60, 30),
+		sigmaY: newParam("sigma Y", 0, 60, 0),
+		border: newBorderEnum(),
+	}
+}
 
-	fmt.Printf("Start reading video: %v\n", videoSource)
+func (f *gaussianBlurFilter) Name() string {
+	return "gaussian"
+}
 
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
+func (f *gaussianBlurFilter) Title() string {
+	return "Gaussian Blur - " + f.border.Description() + " - CVscope"
+}
 
-		// make sure we do not have any invalid values
-		validateGaussianBlurTrackers()
+func (f *gaussianBlurFilter) Params() []*Param {
+	return []*Param{f.ksizeX, f.ksizeY, f.sigmaX, f.sigmaY}
+}
 
-		// GaussianBlur image proccessing filter
-		gocv.GaussianBlur(img, &processed, image.Pt(gaussianKX, gaussianKY), gaussianSX, gaussianSY, getCurrentBorder(currentGaussianBlurBorder))
+func (f *gaussianBlurFilter) Enums() []*Enum {
+	return []*Enum{f.border}
+}
 
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
+// either ksize or sigmax have to be non-zero
+func (f *gaussianBlurFilter) Validate() {
+	if f.sigmaX.Pos() == 0 {
+		if f.ksizeX.Pos() == 0 {
+			f.ksizeX.SetPos(1)
 		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentGaussianBlurBorder = prevBorder(currentGaussianBlurBorder)
-			window.SetWindowTitle(gaussianBlurWindowTitle())
-		case xKey:
-			currentGaussianBlurBorder = nextBorder(currentGaussianBlurBorder)
-			window.SetWindowTitle(gaussianBlurWindowTitle())
-		case gKey:
-			gaussianBlurGoCodeFragment(gaussianKX, gaussianKY, gaussianSX, gaussianSY, getCurrentBorderDescription(currentGaussianBlurBorder))
-		case pKey:
-			gaussianBlurPythonCodeFragment(gaussianKX, gaussianKY, gaussianSX, gaussianSY, currentGaussianBlurBorder)
-		case space:
-			handlePause(gaussianBlurWindowTitle())
-		case wKey:
-			writeFile("gaussian", processed)
-		case esc:
-			return
+		if f.ksizeY.Pos() == 0 {
+			f.ksizeY.SetPos(1)
 		}
 	}
+
+	ensureOdd(f.ksizeX)
+	ensureOdd(f.ksizeY)
 }
 
-// either ksize or sigmax have to be non-zero
-func validateGaussianBlurTrackers() {
-	if gaussianSigmaX.GetPos() == 0 {
-		if gaussianKsizeX.GetPos() == 0 {
-			gaussianKsizeX.SetPos(1)
-		}
-		if gaussianKsizeY.GetPos() == 0 {
-			gaussianKsizeY.SetPos(1)
-		}
+// a ksize of 0 is computed from sigma, and a sigma Y of 0 is the same as sigma X
+func (f *gaussianBlurFilter) paramValue(p *Param) (string, bool) {
+	switch {
+	case (p == f.ksizeX || p == f.ksizeY) && p.Pos() == 0:
+		return "0 (from sigma)", true
+	case p == f.sigmaY && p.Pos() == 0:
+		return fmt.Sprintf("%d (sigma X)", f.sigmaX.Pos()), true
 	}
+	return "", false
+}
 
-	gaussianKX = ensureOdd(gaussianKsizeX)
-	gaussianKY = ensureOdd(gaussianKsizeY)
-	gaussianSX = float64(gaussianSigmaX.GetPos())
-	gaussianSY = float64(gaussianSigmaY.GetPos())
+func (f *gaussianBlurFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.GaussianBlur(src, dst, image.Pt(f.ksizeX.Pos(), f.ksizeY.Pos()),
+		float64(f.sigmaX.Pos()), float64(f.sigmaY.Pos()), gocv.BorderType(f.border.Value()))
 }
 
-func gaussianBlurWindowTitle() string {
-	return "Gaussian Blur - " + getCurrentBorderDescription(currentGaussianBlurBorder) + " - CVscope"
+func (f *gaussianBlurFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.GaussianBlur(%s, &%s, image.Pt(%d, %d), %1.f, %1.f, gocv.%s)",
+		src, dst, f.ksizeX.Pos(), f.ksizeY.Pos(), float64(f.sigmaX.Pos()), float64(f.sigmaY.Pos()), f.border.Description())
 }
 
-func gaussianBlurGoCodeFragment(x, y int, sx, sy float64, borderType string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\ngocv.GaussianBlur(src, &dest, image.Pt(%d, %d), %1.f, %1.f, gocv.%s)\n\n",
-		x, y, sx, sy, borderType)
+func (f *gaussianBlurFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.GaussianBlur(%s, (%d, %d), %1.f, sigmaY=%1.f, borderType=cv.%s)",
+		dst, src, f.ksizeX.Pos(), f.ksizeY.Pos(), float64(f.sigmaX.Pos()), float64(f.sigmaY.Pos()), f.border.OpenCV())
 }
 
-func gaussianBlurPythonCodeFragment(x, y int, sx, sy float64, borderType int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *gaussianBlurFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::GaussianBlur(%s, %s, cv::Size(%d, %d), %1.f, %1.f, %s);",
+		src, dst, f.ksizeX.Pos(), f.ksizeY.Pos(), float64(f.sigmaX.Pos()), float64(f.sigmaY.Pos()), f.border.Cpp())
 }
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gocv.io/x/cvscope/scope"
+	"gocv.io/x/gocv"
+)
+
+// runHeadless applies the filter to the video source without opening any
+// windows, and writes the processed images to files or to stdout. When a code
+// format has been chosen, it writes the code for the filter instead.
+func runHeadless(f Filter) error {
+	if codeFormat != "" {
+		return writeCode(f)
+	}
+
+	if outputFrames < 0 {
+		return fmt.Errorf("--frames must be 0 or more, not %d", outputFrames)
+	}
+
+	video, err := scope.OpenVideoCapture(videoSource)
+	if err != nil {
+		return fmt.Errorf("error opening video: %v", err)
+	}
+	defer video.Close()
+
+	// cameras, streams, and moving test patterns never run out of frames
+	if outputFrames == 0 && !video.IsFile() && !video.IsImage() {
+		return fmt.Errorf("--frames 0 only works for video files and images, use a number of frames for %v", videoSource)
+	}
+
+	img := gocv.NewMat()
+	defer img.Close()
+
+	processed := gocv.NewMat()
+	defer processed.Close()
+
+	rendered := gocv.NewMat()
+	defer rendered.Close()
+
+	fmt.Fprintf(os.Stderr, "Start reading video: %v\n", videoSource)
+
+	// a still image only has a single frame, while a directory or glob of
+	// image files has a frame for each file
+	stills := video.Count()
+	if stills == 0 {
+		stills = 1
+	}
+
+	for frame := 0; outputFrames == 0 || frame < outputFrames; {
+		if video.IsImage() {
+			if frame >= stills {
+				break
+			}
+			if err := video.SetIndex(frame); err != nil {
+				return err
+			}
+		}
+
+		if ok := video.Read(&img); !ok {
+			if frame == 0 {
+				return fmt.Errorf("no images read from video: %v", videoSource)
+			}
+			break
+		}
+		if img.Empty() {
+			// a still image is read again for the same frame, so it would never finish
+			if video.IsImage() {
+				return fmt.Errorf("empty image read from video: %v", videoSource)
+			}
+			continue
+		}
+
+		f.Validate()
+		f.Process(img, &processed)
+
+		// TIFF files keep the raw data, such as the 16-bit output of the
+		// derivative filters, otherwise the output is rendered for display
+		output := processed
+		if !rawOutput() {
+			render(f, img, processed, &rendered)
+			output = rendered
+		}
+
+		if err := writeOutput(f.Name(), frame, output); err != nil {
+			return err
+		}
+		frame++
+	}
+
+	return nil
+}
+
+// writeOutput writes a processed image to stdout when the output is "-",
+// otherwise to the output file for the frame.
+func writeOutput(name string, frame int, img gocv.Mat) error {
+	if outputFile == "-" {
+		buf, err := gocv.IMEncode(gocv.PNGFileExt, img)
+		if err != nil {
+			return err
+		}
+		defer buf.Close()
+
+		_, err = os.Stdout.Write(buf.GetBytes())
+		return err
+	}
+
+	file := outputFileName(name, frame)
+	if ok := gocv.IMWrite(file, img); !ok {
+		return fmt.Errorf("error writing file: %v", file)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote file: %v\n", file)
+	return nil
+}
+
+// frameVerb is a verb for formatting the frame number into the output file
+// name, such as %04d.
+var frameVerb = regexp.MustCompile(`%[0-9]*d`)
+
+// hasFrameVerb reports whether the file name has a single verb for the frame
+// number, with any other percent signs written as %%, so that other names
+// such as 50%.png are used as they are.
+func hasFrameVerb(file string) bool {
+	rest := strings.Replace(file, "%%", "", -1)
+	return strings.Count(rest, "%") == 1 && frameVerb.MatchString(rest)
+}
+
+// rawOutput reports whether the output is a TIFF file, which is written using
+// the raw processed images.
+func rawOutput() bool {
+	switch strings.ToLower(filepath.Ext(outputFile)) {
+	case ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// outputFileName returns the file name for a frame. When more than one frame
+// is written, the frame number is either formatted into the name using a verb
+// such as %04d, or added to the end of the name.
+func outputFileName(name string, frame int) string {
+	file := outputFile
+	if file == "" {
+		file = name + ".jpg"
+	}
+
+	switch {
+	case hasFrameVerb(file):
+		return fmt.Sprintf(file, frame)
+	case outputFrames == 1:
+		return file
+	}
+
+	ext := filepath.Ext(file)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(file, ext), frame, ext)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import "testing"
+
+func TestOutputFileName(t *testing.T) {
+	defer func(file string, frames int) {
+		outputFile, outputFrames = file, frames
+	}(outputFile, outputFrames)
+
+	tests := []struct {
+		output string
+		frames int
+		frame  int
+		want   string
+	}{
+		{"", 1, 0, "blur.jpg"},
+		{"", 0, 3, "blur-0003.jpg"},
+		{"out.png", 1, 0, "out.png"},
+		{"out.png", 5, 2, "out-0002.png"},
+		{"dir/out.tif", 0, 12, "dir/out-0012.tif"},
+		{"frame-%03d.png", 1, 0, "frame-000.png"},
+		{"frame-%03d.png", 10, 7, "frame-007.png"},
+		{"frame%d.png", 0, 42, "frame42.png"},
+
+		// percent signs that are not a single verb for the frame number are
+		// part of the name
+		{"50%.png", 1, 0, "50%.png"},
+		{"50%.png", 3, 1, "50%-0001.png"},
+		{"50%%-%02d.png", 3, 1, "50%-01.png"},
+		{"%s-%d.png", 3, 1, "%s-%d-0001.png"},
+		{"%d-%d.png", 3, 1, "%d-%d-0001.png"},
+	}
+
+	for _, tt := range tests {
+		outputFile, outputFrames = tt.output, tt.frames
+		if got := outputFileName("blur", tt.frame); got != tt.want {
+			t.Errorf("outputFileName(%q, frames %d, frame %d) = %q, want %q", tt.output, tt.frames, tt.frame, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// keyName is how a key is written in the help.
+func keyName(key int) string {
+	switch key {
+	case space:
+		return "space"
+	case esc:
+		return "esc"
+	}
+	return fmt.Sprintf("'%c'", key)
+}
+
+// keyNames joins the names of keys that do the same thing, such as 'z' and 'x'.
+func keyNames(keys []int) string {
+	switch len(keys) {
+	case 1:
+		return keyName(keys[0])
+	case 2:
+		return keyName(keys[0]) + " and " + keyName(keys[1])
+	}
+
+	// ranges of keys such as '1' to '9' are shortened
+	consecutive := true
+	for i := 1; i < len(keys); i++ {
+		consecutive = consecutive && keys[i] == keys[i-1]+1
+	}
+	if consecutive {
+		return keyName(keys[0]) + " to " + keyName(keys[len(keys)-1])
+	}
+
+	var names []string
+	for _, k := range keys[:len(keys)-1] {
+		names = append(names, keyName(k))
+	}
+	return strings.Join(names, ", ") + ", and " + keyName(keys[len(keys)-1])
+}
+
+// keyHelp returns a line of help for each of the keys of the filter, starting
+// with the keys for its enums. Keys next to each other that have the same
+// description are combined into a single line.
+func keyHelp(f Filter, bindings []keyBinding) []string {
+	var lines []string
+	for _, e := range f.Enums() {
+		lines = append(lines, fmt.Sprintf("%s: page through the %s choices", keyNames([]int{e.PrevKey, e.NextKey}), e.Name))
+	}
+
+	var keys []int
+	for i, k := range bindings {
+		keys = append(keys, k.key)
+		if i+1 < len(bindings) && bindings[i+1].desc == k.desc {
+			continue
+		}
+		lines = append(lines, keyNames(keys)+": "+k.desc)
+		keys = nil
+	}
+	return lines
+}
+
+// keyCommands is the help for the keys of the filter, for the Long text of its
+// command. It is generated from the same key bindings used when the filter is
+// running, so it always matches.
+func keyCommands(f Filter) string {
+	r := &runner{filter: f}
+	return "\n\nKey commands:\n  " + strings.Join(keyHelp(f, r.keyBindings()), "\n  ")
+}
+
+func (r *runner) handleHelp() {
+	r.help = !r.help
+}
+
+// drawHelp draws the help for the keys onto the displayed image, in place of
+// the overlay.
+func (r *runner) drawHelp() {
+	if !r.help {
+		return
+	}
+
+	toBGR(r.display, &r.display)
+
+	y := 10
+	for _, line := range keyHelp(r.filter, r.keys) {
+		size := gocv.GetTextSize(line, gocv.FontHersheySimplex, 0.5, 1)
+		gocv.Rectangle(&r.display, image.Rect(6, y, 14+size.X, y+size.Y+8), overlayBackground, -1)
+		gocv.PutText(&r.display, line, image.Pt(10, y+size.Y+4), gocv.FontHersheySimplex, 0.5, overlayColor, 1)
+		y += size.Y + 8
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import "testing"
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		keys []int
+		want string
+	}{
+		{[]int{wKey}, "'w'"},
+		{[]int{esc}, "esc"},
+		{[]int{space}, "space"},
+		{[]int{zKey, xKey}, "'z' and 'x'"},
+		{[]int{oneKey, oneKey + 1, oneKey + 2}, "'1' to '3'"},
+		{[]int{oneKey, oneKey + 1, oneKey + 2, oneKey + 3, oneKey + 4, oneKey + 5, oneKey + 6, oneKey + 7, oneKey + 8}, "'1' to '9'"},
+		{[]int{upperIKey, upperJKey, upperKKey, upperLKey}, "'I' to 'L'"},
+		{[]int{plus, equals, minus}, "'+', '=', and '-'"},
+		{[]int{oneKey, oneKey + 2, oneKey + 3}, "'1', '3', and '4'"},
+	}
+
+	for _, tt := range tests {
+		if got := keyNames(tt.keys); got != tt.want {
+			t.Errorf("keyNames(%v) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestKeyHelp(t *testing.T) {
+	bindings := []keyBinding{
+		{nKey, "next/previous", nil},
+		{bKey, "next/previous", nil},
+		{wKey, "snapshot", nil},
+		{oneKey, "select", nil},
+		{oneKey + 1, "select", nil},
+		{oneKey + 2, "select", nil},
+		{hKey, "help", nil},
+		{questionMark, "help", nil},
+	}
+	want := []string{
+		"'z' and 'x': page through the border choices",
+		"'n' and 'b': next/previous",
+		"'w': snapshot",
+		"'1' to '3': select",
+		"'h' and '?': help",
+	}
+
+	got := keyHelp(newGaussianBlurFilter(), bindings)
+	if len(got) != len(want) {
+		t.Fatalf("keyHelp() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("keyHelp()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
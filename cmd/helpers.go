@@ -6,23 +6,52 @@ import (
 	"gocv.io/x/gocv"
 )
 
-// type CVCommander interface {
-// 	Init()
-// 	Validate()
-// 	WindowTitle() string
-// 	Process(input gocv.Mat, output gocv.Mat)
-// }
-
 const (
-	zKey  = 122
-	xKey  = 120
-	aKey  = 97
-	sKey  = 115
-	gKey  = 103
-	pKey  = 112
-	wKey  = 119
-	space = 32
-	esc   = 27
+	zKey         = 122
+	xKey         = 120
+	aKey         = 97
+	bKey         = 98
+	sKey         = 115
+	cKey         = 99
+	dKey         = 100
+	eKey         = 101
+	upperDKey    = 68
+	fKey         = 102
+	upperFKey    = 70
+	gKey         = 103
+	hKey         = 104
+	iKey         = 105
+	upperGKey    = 71
+	upperIKey    = 73
+	upperJKey    = 74
+	upperKKey    = 75
+	upperLKey    = 76
+	kKey         = 107
+	lKey         = 108
+	mKey         = 109
+	nKey         = 110
+	oKey         = 111
+	pKey         = 112
+	rKey         = 114
+	tKey         = 116
+	uKey         = 117
+	vKey         = 118
+	wKey         = 119
+	yKey         = 121
+	upperWKey    = 87
+	oneKey       = 49
+	leftBracket  = 91
+	rightBracket = 93
+	comma        = 44
+	period       = 46
+	lessThan     = 60
+	greaterThan  = 62
+	plus         = 43
+	minus        = 45
+	equals       = 61
+	questionMark = 63
+	space        = 32
+	esc          = 27
 )
 
 func codeFragmentHeader(lang string) {
@@ -31,96 +60,37 @@ func codeFragmentHeader(lang string) {
 	fmt.Println("-------------------------------")
 }
 
-func prevShape(currentShape int) int {
-	currentShape--
-	if currentShape < 0 {
-		currentShape = 2
-	}
-
-	return currentShape
-}
-
-func nextShape(currentShape int) int {
-	return (currentShape + 1) % 3
-}
-
-func getCurrentMorphShape(currentShape int) gocv.MorphShape {
-	return gocv.MorphShape(currentShape)
-}
-
-func getCurrentMorphShapeDescription(currentShape int) string {
-	switch currentShape {
-	case 0:
-		return "MorphRect"
-	case 1:
-		return "MorphCross"
-	case 2:
-		return "MorphEllipse"
-	}
-
-	return "Unknown"
-}
-
-func getCurrentBorder(currentBorder int) gocv.BorderType {
-	return gocv.BorderType(currentBorder)
-}
-
-func getCurrentBorderDescription(currentBorder int) string {
-	switch currentBorder {
-	case 0:
-		return "BorderConstant"
-	case 1:
-		return "BorderReplicate"
-	case 2:
-		return "BorderReflect"
-	case 4:
-		return "BorderReflect101"
-	}
-
-	return "Unknown"
-}
-
-func prevBorder(currentBorder int) int {
-	currentBorder--
-	if currentBorder < 0 {
-		currentBorder = 4
-	}
-	if currentBorder == 3 {
-		currentBorder = 2
-	}
-
-	return currentBorder
-}
-
-func nextBorder(currentBorder int) int {
-	currentBorder = (currentBorder + 1) % 5
-	if currentBorder == 3 {
-		currentBorder = 4
-	}
-
-	return currentBorder
+func printCode(lang, code string) {
+	codeFragmentHeader(lang)
+	fmt.Printf("%s\n\n", code)
 }
 
 // ksize has to be either 0 or an odd number
-func ensureOdd(tracker *gocv.Trackbar) int {
-	size := tracker.GetPos()
+func ensureOdd(p *Param) int {
+	size := p.Pos()
 
 	if size%2 == 1 || size == 0 {
 		return size
 	}
 
-	tracker.SetPos(size - 1)
+	p.SetPos(size - 1)
 	return size - 1
 }
 
-func handlePause(text string) {
-	pause = !pause
-	if pause {
-		text = "**PAUSED** " + text
-	}
-	window.SetWindowTitle(text)
+// depth returns the type of the elements of an image, such as gocv.MatTypeCV8U.
+func depth(img gocv.Mat) gocv.MatType {
+	return img.Type() & 7
 }
 
-func writeFile(cmdName string, img gocv.Mat) {
-	gocv.IMWrite(cmdName+".jpg", img)
+// toGray converts an image to grayscale, for the filters that only work on
+// grayscale images. Images that are already grayscale are copied as-is.
+func toGray(src gocv.Mat, dst *gocv.Mat) {
+	switch src.Channels() {
+	case 1:
+		src.CopyTo(dst)
+	case 4:
+		gocv.CvtColor(src, dst, gocv.ColorBGRAToGray)
+	default:
+		gocv.CvtColor(src, dst, gocv.ColorBGRToGray)
+	}
 }
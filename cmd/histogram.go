@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	histogramWidth  = 512
+	histogramHeight = 150
+)
+
+var (
+	histogramColors = []color.RGBA{{255, 0, 0, 0}, {0, 255, 0, 0}, {0, 0, 255, 0}}
+	markerColor     = color.RGBA{0, 255, 255, 0}
+)
+
+// allChannels is the channel of a marker that is for every channel.
+const allChannels = -1
+
+// marker is a value that pixel values are compared against, which is marked on
+// the histograms. The channel is the channel of the input that the value is
+// for, or allChannels.
+type marker struct {
+	value   float64
+	channel int
+}
+
+// histogramMarker is implemented by filters that compare pixel values against
+// thresholds or ranges, so that they can be marked on the histograms.
+type histogramMarker interface {
+	histogramMarkers() []marker
+}
+
+// histogramConverter is implemented by filters that compare the pixel values
+// in another color space, so that the histogram of the input is shown in that
+// color space, along with the markers. It returns the label for the histogram.
+type histogramConverter interface {
+	histogramInput(src gocv.Mat, dst *gocv.Mat) string
+}
+
+// handleHistogram shows or hides the window with the histograms of the input
+// and processed images.
+func (r *runner) handleHistogram() {
+	if r.histogram != nil {
+		r.closeHistogram()
+		return
+	}
+	r.histogram = gocv.NewWindow("Histogram - CVscope")
+}
+
+func (r *runner) closeHistogram() {
+	if r.histogram != nil {
+		r.histogram.Close()
+		r.histogram = nil
+	}
+}
+
+// showHistograms shows the histogram of the input image above the histogram
+// of the processed image, when the histogram window is open. Processed images
+// that are not 8-bit, such as the output of sobel, are shown as displayed.
+func (r *runner) showHistograms() {
+	if r.histogram == nil {
+		return
+	}
+
+	var markers, shared []marker
+	if m, ok := r.filter.(histogramMarker); ok {
+		markers = m.histogramMarkers()
+	}
+	for _, m := range markers {
+		if m.channel == allChannels {
+			shared = append(shared, m)
+		}
+	}
+
+	input, inputLabel := r.img, "input"
+	if c, ok := r.filter.(histogramConverter); ok {
+		converted := gocv.NewMat()
+		defer converted.Close()
+		inputLabel = c.histogramInput(r.img, &converted)
+		input = converted
+	}
+
+	processed, label := r.processed, "processed"
+	if depth(processed) != gocv.MatTypeCV8U {
+		processed, label = r.rendered, "processed (as displayed)"
+	}
+
+	canvas := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), 2*histogramHeight, histogramWidth, gocv.MatTypeCV8UC3)
+	defer canvas.Close()
+
+	top := canvas.Region(image.Rect(0, 0, histogramWidth, histogramHeight))
+	drawHistogram(input, &top, inputLabel, markers)
+	top.Close()
+
+	// the markers for a single channel of the input do not apply to the output
+	bottom := canvas.Region(image.Rect(0, histogramHeight, histogramWidth, 2*histogramHeight))
+	drawHistogram(processed, &bottom, label, shared)
+	bottom.Close()
+
+	r.histogram.IMShow(canvas)
+}
+
+// drawHistogram draws the histogram of each channel of the 8-bit image onto
+// dst, in blue, green, and red for color images and in white for grayscale
+// images, with a line for each of the markers. The markers for every channel
+// are yellow, and the markers for a single channel are the color of the
+// histogram of the channel.
+func drawHistogram(img gocv.Mat, dst *gocv.Mat, label string, markers []marker) {
+	gocv.PutText(dst, label, image.Pt(8, 18), gocv.FontHersheySimplex, 0.5, overlayColor, 1)
+
+	channels := img.Channels()
+	if channels > len(histogramColors) {
+		channels = len(histogramColors)
+	}
+
+	mask := gocv.NewMat()
+	defer mask.Close()
+
+	hist := gocv.NewMat()
+	defer hist.Close()
+
+	bottom := histogramHeight - 1
+	for c := 0; c < channels; c++ {
+		gocv.CalcHist([]gocv.Mat{img}, []int{c}, mask, &hist, []int{256}, []float64{0, 256}, false)
+
+		// the tallest bin reaches just below the label
+		gocv.Normalize(hist, &hist, 0, float64(histogramHeight-30), gocv.NormMinMax)
+
+		clr := overlayColor
+		if channels > 1 {
+			clr = histogramColors[c]
+		}
+
+		prev := image.Pt(0, bottom-int(hist.GetFloatAt(0, 0)))
+		for i := 1; i < 256; i++ {
+			pt := image.Pt(i*histogramWidth/256, bottom-int(hist.GetFloatAt(i, 0)))
+			gocv.Line(dst, prev, pt, clr, 1)
+			prev = pt
+		}
+	}
+
+	for _, m := range markers {
+		if m.channel >= channels {
+			continue
+		}
+
+		// the values for each channel are on their own row, so they do not overlap
+		clr, row := markerColor, 0
+		if m.channel != allChannels {
+			row = m.channel
+			if channels > 1 {
+				clr = histogramColors[m.channel]
+			}
+		}
+
+		x := int(m.value) * histogramWidth / 256
+		gocv.Line(dst, image.Pt(x, 24), image.Pt(x, bottom), clr, 1)
+		gocv.PutText(dst, fmt.Sprintf("%.0f", m.value), image.Pt(x+4, 36+12*row), gocv.FontHersheySimplex, 0.4, clr, 1)
+	}
+}
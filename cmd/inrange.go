@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/spf13/cobra"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	registerFilter(inRangeCmd, newInRangeFilter)
+}
+
+var inRangeCmd = &cobra.Command{
+	Use:   "inrange",
+	Short: "Select the pixels of video images within a range of colors",
+	Long:  `Select the pixels of video images within a range of colors, in the BGR, HSV, or Lab color space.`,
+}
+
+// The ways that the mask from inrange can be displayed.
+const (
+	// inRangeMask displays the mask itself.
+	inRangeMask = iota
+
+	// inRangeMasked displays the pixels of the original image that are within
+	// the range, on a black background.
+	inRangeMasked
+
+	// inRangeContours displays the outlines of the areas in the mask on the
+	// original image.
+	inRangeContours
+)
+
+const (
+	// colorSpaceBGR is the color space of the source, which does not need to
+	// be converted.
+	colorSpaceBGR = -1
+
+	// maxHue is the largest hue for 8-bit HSV images, since the hue is halved
+	// to fit.
+	maxHue = 179
+
+	// sampleMargin is added to the ranges of the sampled pixels, so that
+	// similar pixels are also in the ranges.
+	sampleMargin = 10
+)
+
+var contourColor = color.RGBA{0, 255, 0, 0}
+
+type inRangeFilter struct {
+	low, high [3]*Param
+	space     *Enum
+	view      *Enum
+}
+
+func newInRangeFilter() Filter {
+	f := &inRangeFilter{
+		space: &Enum{
+			Name:    "color space",
+			PrevKey: zKey,
+			NextKey: xKey,
+			Options: []Option{
+				{"BGR", colorSpaceBGR, ""},
+				{"HSV", int(gocv.ColorBGRToHSV), "COLOR_BGR2HSV"},
+				{"Lab", int(gocv.ColorBGRToLab), "COLOR_BGR2Lab"},
+			},
+		},
+		view: &Enum{
+			Name:    "view",
+			PrevKey: aKey,
+			NextKey: sKey,
+			Options: []Option{
+				{"Mask", inRangeMask, ""},
+				{"Masked", inRangeMasked, ""},
+				{"Contours", inRangeContours, ""},
+			},
+		},
+	}
+	for c := range f.low {
+		f.low[c] = newParam(fmt.Sprintf("low %d", c+1), 0, 255, 0)
+		f.high[c] = newParam(fmt.Sprintf("high %d", c+1), 0, 255, 255)
+	}
+	return f
+}
+
+func (f *inRangeFilter) Name() string {
+	return "inrange"
+}
+
+func (f *inRangeFilter) Title() string {
+	title := "In Range - " + f.space.Description()
+	if f.hueWraps() {
+		title += " (hue wraps)"
+	}
+	return title + " - " + f.view.Description() + " - CVscope"
+}
+
+func (f *inRangeFilter) Params() []*Param {
+	return []*Param{f.low[0], f.high[0], f.low[1], f.high[1], f.low[2], f.high[2]}
+}
+
+func (f *inRangeFilter) Enums() []*Enum {
+	return []*Enum{f.space, f.view}
+}
+
+// the hue only goes up to 179 for HSV
+func (f *inRangeFilter) Validate() {
+	if !f.isHSV() {
+		return
+	}
+	for _, p := range []*Param{f.low[0], f.high[0]} {
+		if p.Pos() > maxHue {
+			p.SetPos(maxHue)
+		}
+	}
+}
+
+// the values are labeled with the name of their channel
+func (f *inRangeFilter) paramValue(p *Param) (string, bool) {
+	names := f.channelNames()
+	for c := range f.low {
+		if p == f.low[c] || p == f.high[c] {
+			return fmt.Sprintf("%d (%s)", p.Pos(), names[c]), true
+		}
+	}
+	return "", false
+}
+
+// the output is a single channel mask
+func (f *inRangeFilter) outputChannels(input int) int {
+	return 1
+}
+
+// the displayed image is only a mask in the mask view, since the other views
+// show the original image
+func (f *inRangeFilter) maskOutput() bool {
+	return f.view.Value() == inRangeMask
+}
+
+// the low and high values of each channel are marked on its histogram
+func (f *inRangeFilter) histogramMarkers() []marker {
+	var markers []marker
+	for c := range f.low {
+		markers = append(markers, marker{float64(f.low[c].Pos()), c}, marker{float64(f.high[c].Pos()), c})
+	}
+	return markers
+}
+
+// the histogram of the input is in the color space of the ranges
+func (f *inRangeFilter) histogramInput(src gocv.Mat, dst *gocv.Mat) string {
+	f.convert(src, dst)
+	return "input (" + f.space.Description() + ")"
+}
+
+func (f *inRangeFilter) channelNames() []string {
+	if f.space.Value() == colorSpaceBGR {
+		return []string{"B", "G", "R"}
+	}
+	return channelNames[f.space.Value()]
+}
+
+func (f *inRangeFilter) isHSV() bool {
+	return f.space.Value() == int(gocv.ColorBGRToHSV)
+}
+
+// hueWraps returns true when the range of hues goes past the largest hue and
+// back to 0, which is needed for reds.
+func (f *inRangeFilter) hueWraps() bool {
+	return f.isHSV() && f.low[0].Pos() > f.high[0].Pos()
+}
+
+// bounds returns the lower and upper bounds for the range, using the lower
+// and upper hues that are given.
+func (f *inRangeFilter) bounds(lowHue, highHue int) (lb, ub [3]int) {
+	for c := range f.low {
+		lb[c], ub[c] = f.low[c].Pos(), f.high[c].Pos()
+	}
+	lb[0], ub[0] = lowHue, highHue
+	return
+}
+
+// ranges returns the lower and upper bounds for each range that is selected.
+// When the hue wraps, there is a range for the hues up to the largest hue, and
+// another range for the hues from 0.
+func (f *inRangeFilter) ranges() [][2][3]int {
+	lowHue, highHue := f.low[0].Pos(), f.high[0].Pos()
+	if !f.hueWraps() {
+		lb, ub := f.bounds(lowHue, highHue)
+		return [][2][3]int{{lb, ub}}
+	}
+
+	lb1, ub1 := f.bounds(lowHue, maxHue)
+	lb2, ub2 := f.bounds(0, highHue)
+	return [][2][3]int{{lb1, ub1}, {lb2, ub2}}
+}
+
+// convert converts the src image to the color space.
+func (f *inRangeFilter) convert(src gocv.Mat, dst *gocv.Mat) {
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	toBGR(src, &bgr)
+
+	if f.space.Value() == colorSpaceBGR {
+		bgr.CopyTo(dst)
+		return
+	}
+	gocv.CvtColor(bgr, dst, gocv.ColorConversionCode(f.space.Value()))
+}
+
+func scalar(v [3]int) gocv.Scalar {
+	return gocv.NewScalar(float64(v[0]), float64(v[1]), float64(v[2]), 0)
+}
+
+func (f *inRangeFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	converted := gocv.NewMat()
+	defer converted.Close()
+	f.convert(src, &converted)
+
+	ranges := f.ranges()
+	gocv.InRangeWithScalar(converted, scalar(ranges[0][0]), scalar(ranges[0][1]), dst)
+	if len(ranges) == 1 {
+		return
+	}
+
+	wrapped := gocv.NewMat()
+	defer wrapped.Close()
+	gocv.InRangeWithScalar(converted, scalar(ranges[1][0]), scalar(ranges[1][1]), &wrapped)
+	gocv.BitwiseOr(*dst, wrapped, dst)
+}
+
+// display renders the mask using the current view.
+func (f *inRangeFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	if f.view.Value() == inRangeMask {
+		processed.CopyTo(dst)
+		return
+	}
+
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	toBGR(src, &bgr)
+
+	if f.view.Value() == inRangeContours {
+		contours := gocv.FindContours(processed, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+		defer contours.Close()
+		gocv.DrawContours(&bgr, contours, -1, contourColor, 2)
+		bgr.CopyTo(dst)
+		return
+	}
+
+	masked := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), bgr.Rows(), bgr.Cols(), gocv.MatTypeCV8UC3)
+	defer masked.Close()
+	bgr.CopyToWithMask(&masked, processed)
+	masked.CopyTo(dst)
+}
+
+// sample sets the ranges so that they include all of the pixels in the region
+// of the img, with a margin. For HSV, the hues can wrap around, so the range
+// of hues leaves out the largest gap between the hues in the region.
+func (f *inRangeFilter) sample(img gocv.Mat, region image.Rectangle) {
+	region = region.Intersect(image.Rect(0, 0, img.Cols(), img.Rows()))
+	if region.Empty() {
+		return
+	}
+
+	roi := img.Region(region)
+	defer roi.Close()
+
+	converted := gocv.NewMat()
+	defer converted.Close()
+	f.convert(roi, &converted)
+
+	low, high := [3]int{255, 255, 255}, [3]int{0, 0, 0}
+	var hues [maxHue + 1]bool
+	for y := 0; y < converted.Rows(); y++ {
+		for x := 0; x < converted.Cols(); x++ {
+			for c := 0; c < 3; c++ {
+				v := int(converted.GetUCharAt(y, x*3+c))
+				if v < low[c] {
+					low[c] = v
+				}
+				if v > high[c] {
+					high[c] = v
+				}
+				if c == 0 && f.isHSV() {
+					hues[v] = true
+				}
+			}
+		}
+	}
+
+	for c := range f.low {
+		f.low[c].SetPos(clampInt(low[c]-sampleMargin, 0, 255))
+		f.high[c].SetPos(clampInt(high[c]+sampleMargin, 0, 255))
+	}
+
+	if f.isHSV() {
+		lowHue, highHue := hueRange(hues)
+		f.low[0].SetPos(lowHue)
+		f.high[0].SetPos(highHue)
+	}
+}
+
+// hueRange returns the range of the hues that are present, with a margin,
+// which is the range that leaves out the largest gap between them. The lower
+// hue is larger than the upper hue when the range wraps around.
+func hueRange(present [maxHue + 1]bool) (low, high int) {
+	const hues = maxHue + 1
+
+	first := -1
+	for h := range present {
+		if present[h] {
+			first = h
+			break
+		}
+	}
+	if first < 0 {
+		return 0, maxHue
+	}
+
+	// look for the longest run of hues that are not present, starting and
+	// ending with the first hue that is present
+	gapStart, gapLen, runStart, runLen := 0, 0, 0, 0
+	for i := 1; i <= hues; i++ {
+		h := (first + i) % hues
+		if !present[h] {
+			if runLen == 0 {
+				runStart = h
+			}
+			runLen++
+			continue
+		}
+		if runLen > gapLen {
+			gapStart, gapLen = runStart, runLen
+		}
+		runLen = 0
+	}
+
+	// the margin is taken from the gap, as long as some of the gap is left
+	if gapLen <= 2*sampleMargin {
+		return 0, maxHue
+	}
+	low = (gapStart + gapLen - sampleMargin) % hues
+	high = (gapStart - 1 + sampleMargin) % hues
+	return low, high
+}
+
+func (f *inRangeFilter) GoCode(src, dst string, channels int) string {
+	bgr, code := bgrGoCode(src, dst, channels)
+	converted := bgr
+	if f.space.Value() != colorSpaceBGR {
+		converted = dst + "Converted"
+		code += fmt.Sprintf("%s := gocv.NewMat()\ndefer %s.Close()\ngocv.CvtColor(%s, &%s, gocv.ColorBGRTo%s)\n",
+			converted, converted, bgr, converted, f.space.Description())
+	}
+
+	goScalar := func(v [3]int) string {
+		return fmt.Sprintf("gocv.NewScalar(%d, %d, %d, 0)", v[0], v[1], v[2])
+	}
+
+	ranges := f.ranges()
+	if len(ranges) == 1 {
+		return code + fmt.Sprintf("gocv.InRangeWithScalar(%s, %s, %s, &%s)",
+			converted, goScalar(ranges[0][0]), goScalar(ranges[0][1]), dst)
+	}
+
+	// the hue wraps around, so the masks for both ranges are combined
+	return code + fmt.Sprintf(`%[1]sLow := gocv.NewMat()
+defer %[1]sLow.Close()
+gocv.InRangeWithScalar(%[2]s, %[3]s, %[4]s, &%[1]sLow)
+%[1]sHigh := gocv.NewMat()
+defer %[1]sHigh.Close()
+gocv.InRangeWithScalar(%[2]s, %[5]s, %[6]s, &%[1]sHigh)
+gocv.BitwiseOr(%[1]sLow, %[1]sHigh, &%[1]s)`,
+		dst, converted, goScalar(ranges[0][0]), goScalar(ranges[0][1]), goScalar(ranges[1][0]), goScalar(ranges[1][1]))
+}
+
+func (f *inRangeFilter) PythonCode(src, dst string, channels int) string {
+	bgr, code := bgrPythonCode(src, dst, channels)
+	converted := bgr
+	if f.space.Value() != colorSpaceBGR {
+		converted = dst + "_converted"
+		code += fmt.Sprintf("%s = cv.cvtColor(%s, cv.%s)\n", converted, bgr, f.space.OpenCV())
+	}
+
+	tuple := func(v [3]int) string {
+		return fmt.Sprintf("(%d, %d, %d)", v[0], v[1], v[2])
+	}
+
+	ranges := f.ranges()
+	if len(ranges) == 1 {
+		return code + fmt.Sprintf("%s = cv.inRange(%s, %s, %s)", dst, converted, tuple(ranges[0][0]), tuple(ranges[0][1]))
+	}
+
+	// the hue wraps around, so the masks for both ranges are combined
+	return code + fmt.Sprintf("%s = cv.bitwise_or(cv.inRange(%s, %s, %s), cv.inRange(%s, %s, %s))",
+		dst, converted, tuple(ranges[0][0]), tuple(ranges[0][1]), converted, tuple(ranges[1][0]), tuple(ranges[1][1]))
+}
+
+func (f *inRangeFilter) CppCode(src, dst string, channels int) string {
+	bgr, code := bgrCppCode(src, dst, channels)
+	converted := bgr
+	if f.space.Value() != colorSpaceBGR {
+		converted = dst + "Converted"
+		code += fmt.Sprintf("cv::Mat %s;\ncv::cvtColor(%s, %s, %s);\n", converted, bgr, converted, f.space.Cpp())
+	}
+
+	cppScalar := func(v [3]int) string {
+		return fmt.Sprintf("cv::Scalar(%d, %d, %d)", v[0], v[1], v[2])
+	}
+
+	ranges := f.ranges()
+	if len(ranges) == 1 {
+		return code + fmt.Sprintf("cv::inRange(%s, %s, %s, %s);",
+			converted, cppScalar(ranges[0][0]), cppScalar(ranges[0][1]), dst)
+	}
+
+	// the hue wraps around, so the masks for both ranges are combined
+	return code + fmt.Sprintf(`cv::Mat %[1]sLow, %[1]sHigh;
+cv::inRange(%[2]s, %[3]s, %[4]s, %[1]sLow);
+cv::inRange(%[2]s, %[5]s, %[6]s, %[1]sHigh);
+cv::bitwise_or(%[1]sLow, %[1]sHigh, %[1]s);`,
+		dst, converted, cppScalar(ranges[0][0]), cppScalar(ranges[0][1]), cppScalar(ranges[1][0]), cppScalar(ranges[1][1]))
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHueRange(t *testing.T) {
+	// hueSpan is the hues from one hue to another, wrapping around past the
+	// largest hue when from is larger than to
+	hueSpan := func(from, to int) []int {
+		n := to - from + 1
+		if n <= 0 {
+			n += maxHue + 1
+		}
+
+		var hues []int
+		for i := 0; i < n; i++ {
+			hues = append(hues, (from+i)%(maxHue+1))
+		}
+		return hues
+	}
+
+	tests := []struct {
+		name      string
+		hues      []int
+		low, high int
+	}{
+		{"none", nil, 0, maxHue},
+		{"single hue", []int{5}, 175, 15},
+		{"hue 0", []int{0}, 170, 10},
+		{"greens", hueSpan(40, 59), 30, 69},
+		{"reds that wrap", hueSpan(175, 3), 165, 13},
+		{"all hues", hueSpan(0, maxHue), 0, maxHue},
+
+		// the gap is too small to leave out with the margin on both sides
+		{"narrow gap", append(hueSpan(0, 80), hueSpan(101, maxHue)...), 0, maxHue},
+	}
+
+	for _, tt := range tests {
+		var present [maxHue + 1]bool
+		for _, h := range tt.hues {
+			present[h] = true
+		}
+
+		low, high := hueRange(present)
+		if low != tt.low || high != tt.high {
+			t.Errorf("%s: hueRange() = %d, %d, want %d, %d", tt.name, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestInRangeRanges(t *testing.T) {
+	f := newInRangeFilter().(*inRangeFilter)
+	f.space.Next()
+	f.low[1].SetPos(50)
+	f.high[2].SetPos(200)
+
+	f.low[0].SetPos(20)
+	f.high[0].SetPos(40)
+	want := [][2][3]int{{{20, 50, 0}, {40, 255, 200}}}
+	if got := f.ranges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranges() = %v, want %v", got, want)
+	}
+
+	// the reds wrap around, so there is a range on each side of hue 0
+	f.low[0].SetPos(170)
+	f.high[0].SetPos(10)
+	want = [][2][3]int{{{170, 50, 0}, {maxHue, 255, 200}}, {{0, 50, 0}, {10, 255, 200}}}
+	if got := f.ranges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranges() with the hue wrapping = %v, want %v", got, want)
+	}
+}
+
+func TestInRangeMarkers(t *testing.T) {
+	f := newInRangeFilter().(*inRangeFilter)
+	f.low[0].SetPos(20)
+	f.high[0].SetPos(40)
+	f.low[2].SetPos(100)
+
+	want := []marker{{20, 0}, {40, 0}, {0, 1}, {255, 1}, {100, 2}, {255, 2}}
+	if got := f.histogramMarkers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("histogramMarkers() = %v, want %v", got, want)
+	}
+}
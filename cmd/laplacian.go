@@ -4,129 +4,85 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(laplacianCmd)
+	registerFilter(laplacianCmd, newLaplacianFilter)
 }
 
-var currentLaplacianBorder int
-var laplacianSizeTracker, laplacianScaleTracker, laplacianDeltaTracker *gocv.Trackbar
-var laplacianSize int
-var laplacianScale, laplacianDelta float64
-
 var laplacianCmd = &cobra.Command{
 	Use:   "laplacian",
 	Short: "Apply Laplacian to video images",
-	Long: `Apply Laplacian to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through border calculation types.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleLaplacianCmd()
-	},
+	Long:  `Apply Laplacian to video images.`,
 }
 
-func handleLaplacianCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(laplacianWindowTitle())
-	defer window.Close()
-
-	laplacianSizeTracker = window.CreateTrackbar("size", 31)
-	laplacianSizeTracker.SetPos(1)
-
-	laplacianScaleTracker = window.CreateTrackbar("scale", 60)
-	laplacianScaleTracker.SetPos(0)
-
-	laplacianDeltaTracker = window.CreateTrackbar("delta", 60)
-	laplacianDeltaTracker.SetPos(0)
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// make sure we do not have any invalid values
-		validateLaplacianTrackers()
-
-		// Laplacian image proccessing filter
-		gocv.Laplacian(img, &processed, gocv.MatTypeCV16S, laplacianSize, laplacianScale, laplacianDelta, getCurrentBorder(currentLaplacianBorder))
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentLaplacianBorder = prevBorder(currentLaplacianBorder)
-			window.SetWindowTitle(laplacianWindowTitle())
-		case xKey:
-			currentLaplacianBorder = nextBorder(currentLaplacianBorder)
-			window.SetWindowTitle(laplacianWindowTitle())
-		case gKey:
-			laplacianGoCodeFragment(laplacianSize, laplacianScale, laplacianDelta, getCurrentBorderDescription(currentLaplacianBorder))
-		case pKey:
-			laplacianPythonCodeFragment(laplacianSize, laplacianScale, laplacianDelta, currentLaplacianBorder)
-		case space:
-			handlePause(laplacianWindowTitle())
-		case wKey:
-			writeFile("laplacian", processed)
-		case esc:
-			return
-		}
+type laplacianFilter struct {
+	size, scale, delta *Param
+	border, mode       *Enum
+}
+
+func newLaplacianFilter() Filter {
+	return &laplacianFilter{
+		size:   newParam("size", 0, 31, 1),
+		scale:  newParam("scale", 0, 60, 0),
+		delta:  newParam("delta", 0, 60, 0),
+		border: newBorderEnum(),
+		mode:   newDisplayEnum(false),
 	}
 }
 
-// either ksize or sigmax have to be non-zero
-func validateLaplacianTrackers() {
-	if laplacianSizeTracker.GetPos() == 0 {
-		laplacianSizeTracker.SetPos(1)
+func (f *laplacianFilter) Name() string {
+	return "laplacian"
+}
+
+func (f *laplacianFilter) Title() string {
+	return "Laplacian - " + f.border.Description() + " - " + f.mode.Description() + " - CVscope"
+}
+
+func (f *laplacianFilter) Params() []*Param {
+	return []*Param{f.size, f.scale, f.delta}
+}
+
+func (f *laplacianFilter) Enums() []*Enum {
+	return []*Enum{f.border, f.mode}
+}
+
+// size has to be odd and non-zero
+func (f *laplacianFilter) Validate() {
+	if f.size.Pos() == 0 {
+		f.size.SetPos(1)
 	}
 
-	laplacianSize = ensureOdd(laplacianSizeTracker)
-	laplacianScale = float64(laplacianScaleTracker.GetPos())
-	laplacianDelta = float64(laplacianDeltaTracker.GetPos())
+	ensureOdd(f.size)
+}
+
+func (f *laplacianFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.Laplacian(src, dst, gocv.MatTypeCV16S, f.size.Pos(),
+		float64(f.scale.Pos()), float64(f.delta.Pos()), gocv.BorderType(f.border.Value()))
+}
+
+// the output is CV16S
+func (f *laplacianFilter) signedOutput() bool {
+	return true
+}
+
+// display renders the output using the current display mode.
+func (f *laplacianFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	displayDerivative(f.mode, src, processed, dst, nil)
 }
 
-func laplacianWindowTitle() string {
-	return "Laplacian - " + getCurrentBorderDescription(currentLaplacianBorder) + " - CVscope"
+func (f *laplacianFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.Laplacian(%s, &%s, gocv.MatTypeCV16S, %d, %1.f, %1.f, gocv.%s)",
+		src, dst, f.size.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Description())
 }
 
-func laplacianGoCodeFragment(x int, sx, sy float64, borderType string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\ngocv.Laplacian(src, &dest, gocv.MatTypeCV16S, %d, %1.f, %1.f, gocv.%s)\n\n",
-		x, sx, sy, borderType)
+func (f *laplacianFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.Laplacian(%s, cv.CV_16S, ksize=%d, scale=%1.f, delta=%1.f, borderType=cv.%s)",
+		dst, src, f.size.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.OpenCV())
 }
 
-func laplacianPythonCodeFragment(x int, sx, sy float64, borderType int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *laplacianFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::Laplacian(%s, %s, CV_16S, %d, %1.f, %1.f, %s);",
+		src, dst, f.size.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Cpp())
 }
@@ -4,98 +4,62 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(medianBlurCmd)
+	registerFilter(medianBlurCmd, newMedianBlurFilter)
 }
 
 var medianBlurCmd = &cobra.Command{
 	Use:   "medianblur",
 	Short: "MedianBlur video images",
-	Long: `Blur video images using a median filter.
+	Long:  `Blur video images using a median filter.`,
+}
 
-Key commands:
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleMedianBlurCmd()
-	},
+type medianBlurFilter struct {
+	ksize *Param
 }
 
-func handleMedianBlurCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
+func newMedianBlurFilter() Filter {
+	return &medianBlurFilter{
+		ksize: newParam("ksize", 0, 25, 5),
 	}
-	defer video.Close()
-
-	window = gocv.NewWindow(medianBlurWindowTitle())
-	defer window.Close()
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
+}
 
-	tracker := window.CreateTrackbar("ksize", 25)
-	tracker.SetMin(0)
-	tracker.SetPos(5)
+func (f *medianBlurFilter) Name() string {
+	return "medianblur"
+}
 
-	fmt.Printf("Start reading video: %v\n", videoSource)
+func (f *medianBlurFilter) Title() string {
+	return "MedianBlur - CVscope"
+}
 
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
+func (f *medianBlurFilter) Params() []*Param {
+	return []*Param{f.ksize}
+}
 
-		// MedianBlur image processing filter
-		ksize := ensureOdd(tracker)
-		gocv.MedianBlur(img, &processed, ksize)
+func (f *medianBlurFilter) Enums() []*Enum {
+	return nil
+}
 
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
+// ksize has to be odd
+func (f *medianBlurFilter) Validate() {
+	ensureOdd(f.ksize)
+}
 
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case gKey:
-			medianBlurGoCodeFragment(tracker.GetPos())
-		case pKey:
-			medianBlurPythonCodeFragment(tracker.GetPos())
-		case space:
-			handlePause(medianBlurWindowTitle())
-		case wKey:
-			writeFile("medianblur", processed)
-		case esc:
-			return
-		}
-	}
+func (f *medianBlurFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.MedianBlur(src, dst, f.ksize.Pos())
 }
 
-func medianBlurWindowTitle() string {
-	return "MedianBlur - CVscope"
+func (f *medianBlurFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.MedianBlur(%s, &%s, %d)", src, dst, f.ksize.Pos())
 }
 
-func medianBlurGoCodeFragment(x int) {
-	codeFragmentHeader("Go")
-	fmt.Printf("gocv.MedianBlur(src, &dest, %d)\n\n", x)
+func (f *medianBlurFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.medianBlur(%s, %d)", dst, src, f.ksize.Pos())
 }
 
-func medianBlurPythonCodeFragment(x int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *medianBlurFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::medianBlur(%s, %s, %d);", src, dst, f.ksize.Pos())
 }
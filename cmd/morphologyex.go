@@ -5,159 +5,82 @@ import (
 	"image"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(morphologyExCmd)
+	registerFilter(morphologyExCmd, newMorphologyExFilter)
 }
 
-var currentMorphologyExShape int
-var currentMorphOp int
-
 var morphologyExCmd = &cobra.Command{
 	Use:   "morph",
 	Short: "Perform MorphologyEx operations on video images",
-	Long: `Perform MorphologyEx operations on video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through structuring element shapes.
-  Use 'a' and 's' keys to page through morphology operations.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleMorphologyExCmd()
-	},
+	Long:  `Perform MorphologyEx operations on video images.`,
 }
 
-func handleMorphologyExCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(morphologyExWindowTitle())
-	defer window.Close()
-
-	morphologyExTrackerX := window.CreateTrackbar("ksize X", 25)
-	morphologyExTrackerX.SetMin(1)
-	morphologyExTrackerX.SetPos(12)
-
-	morphologyExTrackerY := window.CreateTrackbar("ksize Y", 25)
-	morphologyExTrackerY.SetMin(1)
-	morphologyExTrackerY.SetPos(12)
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// MorphologyEx image proccessing filter
-		kernel := gocv.GetStructuringElement(getCurrentMorphShape(currentMorphologyExShape), image.Pt(morphologyExTrackerX.GetPos(), morphologyExTrackerY.GetPos()))
-		gocv.MorphologyEx(img, &processed, gocv.MorphType(currentMorphOp), kernel)
-		kernel.Close()
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentMorphologyExShape = prevShape(currentMorphologyExShape)
-			window.SetWindowTitle(morphologyExWindowTitle())
-		case xKey:
-			currentMorphologyExShape = nextShape(currentMorphologyExShape)
-			window.SetWindowTitle(morphologyExWindowTitle())
-		case aKey:
-			prevMorphOp()
-			window.SetWindowTitle(morphologyExWindowTitle())
-		case sKey:
-			nextMorphOp()
-			window.SetWindowTitle(morphologyExWindowTitle())
-		case gKey:
-			morphologyExGoCodeFragment(getCurrentMorphShapeDescription(currentMorphologyExShape), morphologyExTrackerX.GetPos(), morphologyExTrackerY.GetPos(), getCurrentMorphOpDescription(currentMorphOp))
-		case pKey:
-			morphologyExPythonCodeFragment(currentMorphologyExShape, morphologyExTrackerX.GetPos(), morphologyExTrackerY.GetPos(), currentMorphOp)
-		case space:
-			handlePause(dilateWindowTitle())
-		case wKey:
-			writeFile("morph", processed)
-		case esc:
-			return
-		}
+type morphologyExFilter struct {
+	ksizeX, ksizeY *Param
+	shape, op      *Enum
+}
+
+func newMorphologyExFilter() Filter {
+	return &morphologyExFilter{
+		ksizeX: newParam("ksize X", 1, 25, 12),
+		ksizeY: newParam("ksize Y", 1, 25, 12),
+		shape:  newMorphShapeEnum(),
+		op: &Enum{
+			Name:    "morph op",
+			PrevKey: aKey,
+			NextKey: sKey,
+			Options: []Option{
+				{"MorphErode", int(gocv.MorphErode), "MORPH_ERODE"},
+				{"MorphDilate", int(gocv.MorphDilate), "MORPH_DILATE"},
+				{"MorphOpen", int(gocv.MorphOpen), "MORPH_OPEN"},
+				{"MorphClose", int(gocv.MorphClose), "MORPH_CLOSE"},
+				{"MorphGradient", int(gocv.MorphGradient), "MORPH_GRADIENT"},
+				{"MorphTophat", int(gocv.MorphTophat), "MORPH_TOPHAT"},
+				{"MorphBlackhat", int(gocv.MorphBlackhat), "MORPH_BLACKHAT"},
+			},
+		},
 	}
 }
 
-func getCurrentMorphOp() gocv.MorphType {
-	return gocv.MorphType(currentMorphOp)
+func (f *morphologyExFilter) Name() string {
+	return "morph"
 }
 
-func getCurrentMorphOpDescription(op int) string {
-	switch op {
-	case 0:
-		return "MorphErode"
-	case 1:
-		return "MorphDilate"
-	case 2:
-		return "MorphOpen"
-	case 3:
-		return "MorphClose"
-	case 4:
-		return "MorphGradient"
-	case 5:
-		return "MorphTophat"
-	case 6:
-		return "MorphBlackhat"
-	}
+func (f *morphologyExFilter) Title() string {
+	return "MorphologyEx - " + f.op.Description() + " - " + f.shape.Description() + " - CVscope"
+}
 
-	return "Unknown"
+func (f *morphologyExFilter) Params() []*Param {
+	return []*Param{f.ksizeX, f.ksizeY}
 }
 
-func prevMorphOp() {
-	currentMorphOp--
-	if currentMorphOp < 0 {
-		currentMorphOp = 6
-	}
+func (f *morphologyExFilter) Enums() []*Enum {
+	return []*Enum{f.shape, f.op}
 }
 
-func nextMorphOp() {
-	currentMorphOp = (currentMorphOp + 1) % 7
+func (f *morphologyExFilter) Validate() {}
+
+func (f *morphologyExFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	kernel := gocv.GetStructuringElement(gocv.MorphShape(f.shape.Value()), image.Pt(f.ksizeX.Pos(), f.ksizeY.Pos()))
+	defer kernel.Close()
+
+	gocv.MorphologyEx(src, dst, gocv.MorphType(f.op.Value()), kernel)
 }
 
-func morphologyExWindowTitle() string {
-	return "MorphologyEx - " + getCurrentMorphOpDescription(currentMorphOp) + " - " +
-		getCurrentMorphShapeDescription(currentMorphologyExShape) +
-		" - CVscope"
+func (f *morphologyExFilter) GoCode(src, dst string, channels int) string {
+	kernel, code := kernelGoCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("gocv.MorphologyEx(%s, &%s, gocv.%s, %s)", src, dst, f.op.Description(), kernel)
 }
 
-func morphologyExGoCodeFragment(morphType string, x, y int, morphOp string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\nkernel := gocv.GetStructuringElement(gocv.%s, image.Pt(%d, %d))\n", morphType, x, y)
-	fmt.Printf("gocv.MorphologyEx(src, &dest, gocv.%s, kernel)\n\n", morphOp)
+func (f *morphologyExFilter) PythonCode(src, dst string, channels int) string {
+	kernel, code := kernelPythonCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("%s = cv.morphologyEx(%s, cv.%s, %s)", dst, src, f.op.OpenCV(), kernel)
 }
 
-func morphologyExPythonCodeFragment(morphType, x, y, morphOp int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *morphologyExFilter) CppCode(src, dst string, channels int) string {
+	kernel, code := kernelCppCode(dst, f.shape, f.ksizeX.Pos(), f.ksizeY.Pos())
+	return code + fmt.Sprintf("cv::morphologyEx(%s, %s, %s, %s);", src, dst, f.op.Cpp(), kernel)
 }
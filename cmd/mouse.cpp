@@ -0,0 +1,16 @@
+#include <stdint.h>
+#include <opencv2/highgui.hpp>
+#include "mouse.h"
+
+// goMouseEvent is exported from mouse.go.
+extern "C" void goMouseEvent(int id, int x, int y);
+
+// onMouse passes the position of the mouse for a window to Go, along with the
+// id of the handler for the window, which is the user data.
+static void onMouse(int event, int x, int y, int flags, void* userdata) {
+    goMouseEvent((int)(intptr_t)userdata, x, y);
+}
+
+void Window_SetMouseCallback(const char* winname, int id) {
+    cv::setMouseCallback(winname, onMouse, (void*)(intptr_t)id);
+}
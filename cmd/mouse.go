@@ -0,0 +1,47 @@
+package cmd
+
+/*
+#include <stdlib.h>
+#include "mouse.h"
+*/
+import "C"
+
+import (
+	"image"
+	"unsafe"
+)
+
+// mouseHandlers handle the mouse events for the windows, with the index of
+// the handler for a window being passed back by OpenCV with each event, and
+// mouseWindows being the index for each window by name.
+var (
+	mouseHandlers []func(pos image.Point)
+	mouseWindows  = map[string]int{}
+)
+
+// setMouseHandler calls handle with the position of the mouse in the image
+// shown in the window whenever the mouse is moved or clicked over it. The
+// version of GoCV that CVscope uses does not have mouse events, so this calls
+// OpenCV directly. It has to be called again after anything else handles the
+// mouse for the window, such as SelectROI.
+func setMouseHandler(window string, handle func(pos image.Point)) {
+	id, ok := mouseWindows[window]
+	if ok {
+		mouseHandlers[id] = handle
+	} else {
+		id = len(mouseHandlers)
+		mouseHandlers = append(mouseHandlers, handle)
+		mouseWindows[window] = id
+	}
+
+	name := C.CString(window)
+	defer C.free(unsafe.Pointer(name))
+	C.Window_SetMouseCallback(name, C.int(id))
+}
+
+//export goMouseEvent
+func goMouseEvent(id, x, y C.int) {
+	if int(id) < len(mouseHandlers) {
+		mouseHandlers[id](image.Pt(int(x), int(y)))
+	}
+}
@@ -0,0 +1,14 @@
+#ifndef _CVSCOPE_MOUSE_H_
+#define _CVSCOPE_MOUSE_H_
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+void Window_SetMouseCallback(const char* winname, int id);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif //_CVSCOPE_MOUSE_H_
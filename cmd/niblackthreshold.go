@@ -4,198 +4,117 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 	"gocv.io/x/gocv/contrib"
 )
 
 func init() {
-	rootCmd.AddCommand(niblackThresholdCmd)
+	registerFilter(niblackThresholdCmd, newNiblackThresholdFilter)
 }
 
-var (
-	currentBinarizationMethod   int
-	//currentAThreshold          int
-	//blockSize                  int
-	kValue, rValue                     float32
-	kTracker, rTracker *gocv.Trackbar
-)
-
 var niblackThresholdCmd = &cobra.Command{
 	Use:   "niblack",
 	Short: "Apply Niblack threshold to video images",
-	Long: `Apply Niblack threshold to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through Niblack threshold calculation types.
-  Use 'a' and 's' keys to page through threshold calculation types.
-  Press 'esc' to exit.
-  Press 'w' to write JPG file.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleNiblackThresholdCmd()
-	},
-}
-
-func handleNiblackThresholdCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
+	Long:  `Apply Niblack threshold to video images.`,
+}
 
-	window = gocv.NewWindow(niblackThresholdWindowTitle())
-	defer window.Close()
+type niblackThresholdFilter struct {
+	blockSize, k, r *Param
+	method, typ     *Enum
+}
 
-	img := gocv.NewMat()
-	defer img.Close()
+func newNiblackThresholdFilter() Filter {
+	return &niblackThresholdFilter{
+		blockSize: newParam("block size", 0, 255, 3),
+		k:         newParam("k", 0, 10, 5),
+		r:         newParam("r", 0, 512, 128),
+		method: &Enum{
+			Name:    "binarization method",
+			PrevKey: zKey,
+			NextKey: xKey,
+			Options: []Option{
+				{"BinarizationNiblack", int(contrib.BinarizationNiblack), "ximgproc.BINARIZATION_NIBLACK"},
+				{"BinarizationSauvola", int(contrib.BinarizationSauvola), "ximgproc.BINARIZATION_SAUVOLA"},
+				{"BinarizationWolf", int(contrib.BinarizationWolf), "ximgproc.BINARIZATION_WOLF"},
+				{"BinarizationNICK", int(contrib.BinarizationNICK), "ximgproc.BINARIZATION_NICK"},
+			},
+		},
+		typ: newThresholdEnum(aKey, sKey, 2),
+	}
+}
 
-	processed := gocv.NewMat()
-	defer processed.Close()
+func (f *niblackThresholdFilter) Name() string {
+	return "niblack"
+}
 
-	gray := gocv.NewMat()
-	defer gray.Close()
+func (f *niblackThresholdFilter) Title() string {
+	return "niBlackThreshold - " + f.method.Description() + " - " + f.typ.Description() + " - CVscope"
+}
 
-	blockSizeTracker = window.CreateTrackbar("block size", 255)
-	blockSizeTracker.SetPos(3)
-
-	kTracker = window.CreateTrackbar("k", 10)
-	kTracker.SetPos(5)
-
-	rTracker = window.CreateTrackbar("r", 512)
-	rTracker.SetPos(128)
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// make sure we do not have any invalid values
-		validateNiblackThresholdTrackers()
-
-		// only works on grayscale images
-		gocv.CvtColor(img, &gray, gocv.ColorBGRAToGray)
-
-		// NiblackThreshold image processing filter
-		contrib.NiblackThreshold(gray, &processed, 255.0, getCurrentAThreshold(), blockSize, kValue, getCurrentBinarizationMethod(), rValue)
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			prevBinarizationMethod()
-			window.SetWindowTitle(niblackThresholdWindowTitle())
-		case xKey:
-			nextBinarizationMethod()
-			window.SetWindowTitle(niblackThresholdWindowTitle())
-		case aKey:
-			prevAThreshold()
-			window.SetWindowTitle(niblackThresholdWindowTitle())
-		case sKey:
-			nextAThreshold()
-			window.SetWindowTitle(niblackThresholdWindowTitle())
-		case gKey:
-			niblackThresholdGoCodeFragment(255.0, getCurrentBinarizationMethodDescription(), getCurrentAThresholdDescription(), blockSize, kValue)
-		case pKey:
-			niblackThresholdPythonCodeFragment(255.0, getCurrentBinarizationMethodDescription(), getCurrentAThresholdDescription(), blockSize, kValue)
-		case space:
-			handlePause(niblackThresholdWindowTitle())
-		case wKey:
-			writeFile("niblack", processed)
-		case esc:
-			return
-		}
-	}
+func (f *niblackThresholdFilter) Params() []*Param {
+	return []*Param{f.blockSize, f.k, f.r}
 }
 
-// blocksize has to be odd. k value ranges from 0.0 to 1.0.
-func validateNiblackThresholdTrackers() {
-	blockSize = ensureOdd(blockSizeTracker)
-	kValue = float32(kTracker.GetPos())/10.0
-	rValue = float32(rTracker.GetPos())
+func (f *niblackThresholdFilter) Enums() []*Enum {
+	return []*Enum{f.method, f.typ}
 }
 
-func getCurrentBinarizationMethod() contrib.BinarizationMethod {
-	return contrib.BinarizationMethod(currentBinarizationMethod)
+// blocksize has to be odd.
+func (f *niblackThresholdFilter) Validate() {
+	ensureOdd(f.blockSize)
 }
 
-func getCurrentBinarizationMethodDescription() string {
-	switch currentBinarizationMethod {
-	case 0:
-		return "BinarizationNiblack"
-	case 1:
-		return "BinarizationSauvola"
-	case 2:
-		return "BinarizationWolf"
-	case 3:
-		return "BinarizationNICK"
-	}
+// kValue ranges from 0.0 to 1.0.
+func (f *niblackThresholdFilter) kValue() float32 {
+	return float32(f.k.Pos()) / 10.0
+}
 
-	return "Unknown"
+func (f *niblackThresholdFilter) rValue() float32 {
+	return float32(f.r.Pos())
 }
 
-func prevBinarizationMethod() {
-	currentBinarizationMethod--
-	if currentBinarizationMethod < 0 {
-		currentBinarizationMethod = 3
+func (f *niblackThresholdFilter) paramValue(p *Param) (string, bool) {
+	if p == f.k {
+		return fmt.Sprintf("%.1f", f.kValue()), true
 	}
+	return "", false
 }
 
-func nextBinarizationMethod() {
-	currentBinarizationMethod = (currentBinarizationMethod + 1) % 4
+// the input is converted to grayscale
+func (f *niblackThresholdFilter) outputChannels(input int) int {
+	return 1
 }
 
-// func getCurrentAThreshold() gocv.ThresholdType {
-// 	return gocv.ThresholdType(currentAThreshold)
-// }
-
-// func getCurrentAThresholdDescription() string {
-// 	switch currentAThreshold {
-// 	case 0:
-// 		return "ThresholdBinary"
-// 	case 1:
-// 		return "ThresholdBinaryInv"
-// 	}
+// the output is a mask
+func (f *niblackThresholdFilter) maskOutput() bool {
+	return true
+}
 
-// 	return "Unknown"
-// }
+func (f *niblackThresholdFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gray := gocv.NewMat()
+	defer gray.Close()
 
-// func prevAThreshold() {
-// 	currentAThreshold--
-// 	if currentAThreshold < 0 {
-// 		currentAThreshold = 1
-// 	}
-// }
+	// only works on grayscale images
+	toGray(src, &gray)
 
-// func nextAThreshold() {
-// 	currentAThreshold = (currentAThreshold + 1) % 2
-// }
+	contrib.NiblackThreshold(gray, dst, 255.0, gocv.ThresholdType(f.typ.Value()), f.blockSize.Pos(),
+		f.kValue(), contrib.BinarizationMethod(f.method.Value()), f.rValue())
+}
 
-func niblackThresholdWindowTitle() string {
-	return "niBlackThreshold - " + getCurrentBinarizationMethodDescription() + " - " + getCurrentAThresholdDescription() + " - CVscope"
+func (f *niblackThresholdFilter) GoCode(src, dst string, channels int) string {
+	gray, code := grayGoCode(src, dst, channels)
+	return code + fmt.Sprintf("contrib.NiblackThreshold(%s, &%s, %1.f, gocv.%s, %d, %.1f, contrib.%s, %1.f)",
+		gray, dst, 255.0, f.typ.Description(), f.blockSize.Pos(), f.kValue(), f.method.Description(), f.rValue())
 }
 
-func niblackThresholdGoCodeFragment(mv float32, at string, t string, bs int, c float32) {
-	codeFragmentHeader("Go")
-	fmt.Printf("contrib.NiblackThreshold(src, &dest, %1.f, gocv.%s, gocv.%s, %d, %1.f)\n\n", mv, at, t, bs, c)
+func (f *niblackThresholdFilter) PythonCode(src, dst string, channels int) string {
+	gray, code := grayPythonCode(src, dst, channels)
+	return code + fmt.Sprintf("%s = cv.ximgproc.niBlackThreshold(%s, %1.f, cv.%s, %d, %.1f, binarizationMethod=cv.%s, r=%1.f)",
+		dst, gray, 255.0, f.typ.OpenCV(), f.blockSize.Pos(), f.kValue(), f.method.OpenCV(), f.rValue())
 }
 
-func niblackThresholdPythonCodeFragment(mv float32, at string, t string, bs int, c float32) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *niblackThresholdFilter) CppCode(src, dst string, channels int) string {
+	gray, code := grayCppCode(src, dst, channels)
+	return code + fmt.Sprintf("cv::ximgproc::niBlackThreshold(%s, %s, %1.f, %s, %d, %.1f, %s, %1.f);",
+		gray, dst, 255.0, f.typ.Cpp(), f.blockSize.Pos(), f.kValue(), f.method.Cpp(), f.rValue())
 }
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// paramValuer is implemented by filters that have parameters whose values are
+// not the same as their trackbar positions, so that the overlay can show the
+// values that are actually used.
+type paramValuer interface {
+	paramValue(p *Param) (string, bool)
+}
+
+var (
+	overlayColor      = color.RGBA{255, 255, 255, 0}
+	overlayBackground = color.RGBA{0, 0, 0, 0}
+)
+
+// overlayLines are the lines of text for the overlay, with the effective
+// values of the parameters, the enum choices, the frame size, and timings.
+func (r *runner) overlayLines() []string {
+	lines := []string{r.filter.Name()}
+
+	v, _ := r.filter.(paramValuer)
+	for _, p := range r.filter.Params() {
+		label := p.Name
+		if p.Label != "" {
+			label = p.Label
+		}
+
+		value := fmt.Sprint(p.Pos())
+		if v != nil {
+			if s, ok := v.paramValue(p); ok {
+				value = s
+			}
+		}
+		lines = append(lines, label+": "+value)
+	}
+
+	for _, e := range r.filter.Enums() {
+		lines = append(lines, e.Name+": "+e.Description())
+	}
+
+	return append(lines,
+		fmt.Sprintf("size: %dx%d", r.img.Cols(), r.img.Rows()),
+		fmt.Sprintf("process: %.1f ms", float64(r.processTime)/float64(time.Millisecond)),
+		fmt.Sprintf("fps: %.1f", r.fps),
+	)
+}
+
+// drawOverlay draws the overlay text onto the displayed image, below the
+// REC indicator. The values for the pixel inspector come first when it is on.
+func (r *runner) drawOverlay() {
+	if !(r.overlay || r.inspect) || r.help {
+		return
+	}
+
+	toBGR(r.display, &r.display)
+
+	var lines []string
+	if r.inspect {
+		lines = r.inspectLines()
+	}
+	if r.overlay {
+		lines = append(lines, r.overlayLines()...)
+	}
+
+	y := 40
+	for _, line := range lines {
+		size := gocv.GetTextSize(line, gocv.FontHersheySimplex, 0.5, 1)
+		gocv.Rectangle(&r.display, image.Rect(6, y, 14+size.X, y+size.Y+8), overlayBackground, -1)
+		gocv.PutText(&r.display, line, image.Pt(10, y+size.Y+4), gocv.FontHersheySimplex, 0.5, overlayColor, 1)
+		y += size.Y + 8
+	}
+}
+
+// updateFPS measures the rate that frames are displayed, smoothed so that it
+// can be read.
+func (r *runner) updateFPS() {
+	now := time.Now()
+	if !r.lastLoop.IsZero() {
+		if elapsed := now.Sub(r.lastLoop).Seconds(); elapsed > 0 {
+			r.fps = r.fps*0.9 + 0.1/elapsed
+		}
+	}
+	r.lastLoop = now
+}
+
+func (r *runner) handleOverlay() {
+	r.overlay = !r.overlay
+}
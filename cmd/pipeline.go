@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gocv.io/x/gocv"
+)
+
+var pipelineFileName string
+
+func init() {
+	rootCmd.AddCommand(pipelineCmd)
+	pipelineCmd.Long += keyCommands(&pipelineFilter{})
+	pipelineCmd.Flags().StringVar(&pipelineFileName, "pipeline", "", "YAML or JSON file to load the pipeline from, and to save it to using the 'k' key.")
+}
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline [filter...]",
+	Short: "Apply a chain of filters to video images",
+	Long: `Apply a chain of filters to video images, with the output of each filter
+being used as the input to the next one. For example:
+
+  cvscope pipeline gaussian canny dilate
+
+The pipeline can also be loaded from a YAML or JSON file, which is saved with
+the current settings when pressing 'k':
+
+  cvscope pipeline --pipeline edges.yaml
+
+Each stage in the pipeline has its own set of trackbars, which are labeled
+using the number of the stage. The other settings of the current stage are
+paged through using the same keys as its filter.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var p *pipelineFilter
+		var err error
+
+		switch {
+		case pipelineFileName != "" && len(args) > 0:
+			return fmt.Errorf("use either a pipeline file or a list of filters, not both")
+		case pipelineFileName != "":
+			cmd.SilenceUsage = true
+			p, err = loadPipelineFile(pipelineFileName)
+		case len(args) > 0:
+			cmd.SilenceUsage = true
+			p, err = newPipelineFilter(args)
+		default:
+			return fmt.Errorf("requires a pipeline file or a list of filters")
+		}
+		if err != nil {
+			return err
+		}
+		defer p.close()
+
+		// make sure we do not start with any invalid values
+		p.Validate()
+
+		if headless {
+			return runHeadless(p)
+		}
+		runFilter(p)
+		return nil
+	},
+}
+
+// pipelineStage is a single filter in a pipeline.
+type pipelineStage struct {
+	id      int
+	filter  Filter
+	enabled bool
+}
+
+func (s *pipelineStage) label() string {
+	return fmt.Sprintf("%d:%s", s.id, s.filter.Name())
+}
+
+// signedFilter is implemented by filters whose output is signed, such as the
+// CV16S output of the derivative filters, so that it is known without
+// processing a frame.
+type signedFilter interface {
+	signedOutput() bool
+}
+
+// signed reports whether the output of the filter is signed, so it has to be
+// converted before it can be used as the input to the next stage.
+func (s *pipelineStage) signed() bool {
+	f, ok := s.filter.(signedFilter)
+	return ok && f.signedOutput()
+}
+
+// pipelineFilter is a Filter that applies a chain of filters.
+type pipelineFilter struct {
+	stages   []*pipelineStage
+	selected int
+
+	// view is the stage whose output is displayed, or nil for the final output.
+	view *pipelineStage
+
+	// last is the last stage that was applied, and input is a copy of its input
+	// when it is a displayer, so that its output can be rendered.
+	last  *pipelineStage
+	input gocv.Mat
+}
+
+func newPipelineFilter(names []string) (*pipelineFilter, error) {
+	p := &pipelineFilter{}
+	for i, name := range names {
+		f, err := newFilter(name)
+		if err != nil {
+			return nil, err
+		}
+
+		s := &pipelineStage{id: i + 1, filter: f, enabled: true}
+		for _, param := range f.Params() {
+			param.Label = fmt.Sprintf("%d:%s", s.id, param.Name)
+		}
+		p.stages = append(p.stages, s)
+	}
+
+	p.input = gocv.NewMat()
+	return p, nil
+}
+
+// close closes the copy of the input to the last stage.
+func (p *pipelineFilter) close() {
+	p.input.Close()
+}
+
+func (p *pipelineFilter) Name() string {
+	return "pipeline"
+}
+
+func (p *pipelineFilter) Title() string {
+	var labels []string
+	for i, s := range p.stages {
+		label := s.label()
+		if !s.enabled {
+			label += " (off)"
+		}
+		if i == p.selected {
+			label = "[" + label + "]"
+		}
+		labels = append(labels, label)
+	}
+
+	title := "Pipeline - " + strings.Join(labels, " > ") + " - " +
+		strings.TrimSuffix(p.stages[p.selected].filter.Title(), " - CVscope")
+	if p.view != nil {
+		title += " - showing " + p.view.label()
+	}
+	return title + " - CVscope"
+}
+
+func (p *pipelineFilter) Params() []*Param {
+	var params []*Param
+	for _, s := range p.stages {
+		params = append(params, s.filter.Params()...)
+	}
+	return params
+}
+
+// Enums are the enums of the current stage, so that the same keys can be used
+// to page through the settings of any stage.
+func (p *pipelineFilter) Enums() []*Enum {
+	if len(p.stages) == 0 {
+		return nil
+	}
+	return p.stages[p.selected].filter.Enums()
+}
+
+func (p *pipelineFilter) Validate() {
+	for _, s := range p.stages {
+		s.filter.Validate()
+	}
+}
+
+func (p *pipelineFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	var outputs []gocv.Mat
+	defer func() {
+		for _, m := range outputs {
+			m.Close()
+		}
+	}()
+
+	p.last = nil
+	current := src
+	for _, s := range p.stages {
+		if s.enabled {
+			if depth(current) != gocv.MatTypeCV8U {
+				converted := gocv.NewMat()
+				gocv.ConvertScaleAbs(current, &converted, 1, 0)
+				outputs = append(outputs, converted)
+				current = converted
+			}
+
+			if _, ok := s.filter.(displayer); ok {
+				current.CopyTo(&p.input)
+			}
+
+			out := gocv.NewMat()
+			s.filter.Process(current, &out)
+			p.last = s
+			outputs = append(outputs, out)
+			current = out
+		}
+
+		if s == p.view {
+			break
+		}
+	}
+
+	current.CopyTo(dst)
+}
+
+// display renders the output using the display mode of the last stage that
+// was applied.
+func (p *pipelineFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	if p.last != nil {
+		if d, ok := p.last.filter.(displayer); ok {
+			d.display(p.input, processed, dst)
+			return
+		}
+	}
+	render(nil, src, processed, dst)
+}
+
+// paramValue is the value of the parameter from the stage that it belongs to.
+func (p *pipelineFilter) paramValue(param *Param) (string, bool) {
+	for _, s := range p.stages {
+		if v, ok := s.filter.(paramValuer); ok {
+			if value, ok := v.paramValue(param); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// maskOutput reports whether the output of the last stage that was applied is
+// a mask.
+func (p *pipelineFilter) maskOutput() bool {
+	return p.last != nil && isMask(p.last.filter)
+}
+
+func (p *pipelineFilter) enabledStages() []*pipelineStage {
+	var stages []*pipelineStage
+	for _, s := range p.stages {
+		if s.enabled {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// GoCode is the Go code for all of the stages that are turned on, with the
+// output of each stage in its own Mat that is used as the input to the next.
+// The number of channels is followed from stage to stage, so that each stage
+// only converts its input when it needs to.
+func (p *pipelineFilter) GoCode(src, dst string, channels int) string {
+	stages := p.enabledStages()
+	if len(stages) == 0 {
+		return fmt.Sprintf("%s.CopyTo(&%s)", src, dst)
+	}
+
+	var blocks []string
+	in := src
+	for i, s := range stages {
+		out := dst
+		block := []string{"// " + s.filter.Name()}
+		if i < len(stages)-1 {
+			out = fmt.Sprintf("stage%d", i+1)
+			block = append(block, fmt.Sprintf("%s := gocv.NewMat()\ndefer %s.Close()", out, out))
+		}
+		block = append(block, s.filter.GoCode(in, out, channels))
+		in = out
+		channels = outputChannels(s.filter, channels)
+
+		if s.signed() && i < len(stages)-1 {
+			in = out + "Abs"
+			block = append(block, fmt.Sprintf("%s := gocv.NewMat()\ndefer %s.Close()\ngocv.ConvertScaleAbs(%s, &%s, 1, 0)", in, in, out, in))
+		}
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// PythonCode is the Python code for all of the stages that are turned on.
+func (p *pipelineFilter) PythonCode(src, dst string, channels int) string {
+	stages := p.enabledStages()
+	if len(stages) == 0 {
+		return fmt.Sprintf("%s = %s.copy()", dst, src)
+	}
+
+	var blocks []string
+	in := src
+	for i, s := range stages {
+		out := dst
+		if i < len(stages)-1 {
+			out = fmt.Sprintf("stage%d", i+1)
+		}
+		block := []string{"# " + s.filter.Name(), s.filter.PythonCode(in, out, channels)}
+		in = out
+		channels = outputChannels(s.filter, channels)
+
+		if s.signed() && i < len(stages)-1 {
+			in = out + "_abs"
+			block = append(block, fmt.Sprintf("%s = cv.convertScaleAbs(%s)", in, out))
+		}
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// CppCode is the C++ code for all of the stages that are turned on.
+func (p *pipelineFilter) CppCode(src, dst string, channels int) string {
+	stages := p.enabledStages()
+	if len(stages) == 0 {
+		return fmt.Sprintf("%s.copyTo(%s);", src, dst)
+	}
+
+	var blocks []string
+	in := src
+	for i, s := range stages {
+		out := dst
+		block := []string{"// " + s.filter.Name()}
+		if i < len(stages)-1 {
+			out = fmt.Sprintf("stage%d", i+1)
+			block = append(block, fmt.Sprintf("cv::Mat %s;", out))
+		}
+		block = append(block, s.filter.CppCode(in, out, channels))
+		in = out
+		channels = outputChannels(s.filter, channels)
+
+		if s.signed() && i < len(stages)-1 {
+			in = out + "Abs"
+			block = append(block, fmt.Sprintf("cv::Mat %s;\ncv::convertScaleAbs(%s, %s);", in, out, in))
+		}
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (p *pipelineFilter) keyBindings() []keyBinding {
+	var keys []keyBinding
+	for i := 0; i < 9; i++ {
+		i := i
+		keys = append(keys, keyBinding{oneKey + i, "select the current stage", func() {
+			if i < len(p.stages) {
+				p.selected = i
+			}
+		}})
+	}
+
+	return append(keys,
+		keyBinding{tKey, "turn the current stage on or off", p.toggleStage},
+		keyBinding{leftBracket, "move the current stage earlier/later in the pipeline", func() { p.moveStage(-1) }},
+		keyBinding{rightBracket, "move the current stage earlier/later in the pipeline", func() { p.moveStage(1) }},
+		keyBinding{vKey, "page through showing the output of each stage, or the final output", p.nextView},
+		keyBinding{kKey, "save the pipeline file", p.save},
+	)
+}
+
+func (p *pipelineFilter) toggleStage() {
+	s := p.stages[p.selected]
+	s.enabled = !s.enabled
+}
+
+func (p *pipelineFilter) moveStage(offset int) {
+	to := p.selected + offset
+	if to < 0 || to >= len(p.stages) {
+		return
+	}
+
+	p.stages[p.selected], p.stages[to] = p.stages[to], p.stages[p.selected]
+	p.selected = to
+}
+
+// nextView pages through displaying the output of each stage in order, and then
+// the final output.
+func (p *pipelineFilter) nextView() {
+	if p.view == nil {
+		p.view = p.stages[0]
+		return
+	}
+
+	for i, s := range p.stages {
+		if s == p.view {
+			if i == len(p.stages)-1 {
+				p.view = nil
+			} else {
+				p.view = p.stages[i+1]
+			}
+			return
+		}
+	}
+}
+
+// save writes the pipeline to the file it was loaded from, or to pipeline.yaml.
+func (p *pipelineFilter) save() {
+	file := pipelineFileName
+	if file == "" {
+		file = "pipeline.yaml"
+	}
+
+	if err := savePipelineFile(p, file); err != nil {
+		fmt.Printf("Error saving pipeline: %v\n", err)
+	}
+}
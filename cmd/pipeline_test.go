@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPipelineCode(t *testing.T) {
+	tests := []struct {
+		stages []string
+
+		// want and notWant are the parts of the code that must and must not
+		// be in the Go, Python, and C++ code, in that order
+		want, notWant [3][]string
+	}{
+		{
+			stages: []string{"gaussian", "canny", "dilate"},
+			want: [3][]string{
+				{"gocv.GaussianBlur(src, &stage1,", "gocv.CvtColor(stage1, &stage2Gray, gocv.ColorBGRToGray)", "gocv.Dilate(stage2, &dest,"},
+				{"stage1 = cv.GaussianBlur(src,", "stage2_gray = cv.cvtColor(stage1, cv.COLOR_BGR2GRAY)", "dest = cv.dilate(stage2,"},
+				{"cv::GaussianBlur(src, stage1,", "cv::cvtColor(stage1, stage2Gray, cv::COLOR_BGR2GRAY);", "cv::dilate(stage2, dest,"},
+			},
+		},
+		{
+			// the second canny already has a grayscale input
+			stages: []string{"canny", "canny"},
+			want: [3][]string{
+				{"gocv.Canny(stage1, &dest,"},
+				{"dest = cv.Canny(stage1,"},
+				{"cv::Canny(stage1, dest,"},
+			},
+			notWant: [3][]string{{"destGray"}, {"dest_gray"}, {"destGray"}},
+		},
+		{
+			// the signed output of sobel is converted for canny
+			stages: []string{"sobel", "canny"},
+			want: [3][]string{
+				{"gocv.ConvertScaleAbs(stage1, &stage1Abs, 1, 0)", "gocv.CvtColor(stage1Abs, &destGray,"},
+				{"stage1_abs = cv.convertScaleAbs(stage1)", "cv.cvtColor(stage1_abs,"},
+				{"cv::convertScaleAbs(stage1, stage1Abs);", "cv::cvtColor(stage1Abs, destGray,"},
+			},
+		},
+		{
+			// the grayscale output of canny is converted for cvtcolor
+			stages: []string{"canny", "cvtcolor"},
+			want: [3][]string{
+				{"gocv.CvtColor(stage1, &destBGR, gocv.ColorGrayToBGR)", "gocv.CvtColor(destBGR, &dest,"},
+				{"dest_bgr = cv.cvtColor(stage1, cv.COLOR_GRAY2BGR)", "dest = cv.cvtColor(dest_bgr,"},
+				{"cv::cvtColor(stage1, destBGR, cv::COLOR_GRAY2BGR);", "cv::cvtColor(destBGR, dest,"},
+			},
+		},
+		{
+			// the grayscale conversion is already grayscale for canny
+			stages: []string{"cvtcolor", "canny"},
+			want: [3][]string{
+				{"gocv.CvtColor(src, &stage1,", "gocv.Canny(stage1, &dest,"},
+				{"stage1 = cv.cvtColor(src,", "dest = cv.Canny(stage1,"},
+				{"cv::cvtColor(src, stage1,", "cv::Canny(stage1, dest,"},
+			},
+			notWant: [3][]string{{"stage1BGR", "destGray"}, {"stage1_bgr", "dest_gray"}, {"stage1BGR", "destGray"}},
+		},
+		{
+			// the grayscale output of canny is converted for inrange
+			stages: []string{"canny", "inrange"},
+			want: [3][]string{
+				{"gocv.CvtColor(stage1, &destBGR, gocv.ColorGrayToBGR)", "gocv.InRangeWithScalar(destBGR,"},
+				{"dest_bgr = cv.cvtColor(stage1, cv.COLOR_GRAY2BGR)", "dest = cv.inRange(dest_bgr,"},
+				{"cv::cvtColor(stage1, destBGR, cv::COLOR_GRAY2BGR);", "cv::inRange(destBGR,"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := newPipelineFilter(tt.stages)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		codes := [3]string{
+			p.GoCode("src", "dest", bgrChannels),
+			p.PythonCode("src", "dest", bgrChannels),
+			p.CppCode("src", "dest", bgrChannels),
+		}
+		p.close()
+
+		for i, code := range codes {
+			for _, want := range tt.want[i] {
+				if !strings.Contains(code, want) {
+					t.Errorf("%v: code does not contain %q:\n%s", tt.stages, want, code)
+				}
+			}
+			for _, notWant := range tt.notWant[i] {
+				if strings.Contains(code, notWant) {
+					t.Errorf("%v: code contains %q:\n%s", tt.stages, notWant, code)
+				}
+			}
+		}
+	}
+}
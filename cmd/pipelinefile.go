@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// pipelineFile is the contents of a file that describes a pipeline, for example:
+//
+//	stages:
+//	- filter: gaussian
+//	  enabled: true
+//	  params:
+//	    ksize-x: 5
+//	    border: BorderReflect
+//	- filter: canny
+//	  params:
+//	    t1: 40
+//
+// The params use the same keys as the flags for each filter. Both YAML and JSON
+// files are supported, based on the file extension.
+type pipelineFile struct {
+	Stages []struct {
+		Filter  string
+		Enabled *bool
+		Params  map[string]interface{}
+	}
+}
+
+// loadPipelineFile returns the pipeline described in a file.
+func loadPipelineFile(file string) (*pipelineFilter, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var pf pipelineFile
+	if err := v.Unmarshal(&pf); err != nil {
+		return nil, err
+	}
+	if len(pf.Stages) == 0 {
+		return nil, fmt.Errorf("no stages in pipeline file: %s", file)
+	}
+
+	var names []string
+	for _, s := range pf.Stages {
+		names = append(names, s.Filter)
+	}
+
+	p, err := newPipelineFilter(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range pf.Stages {
+		if s.Enabled != nil {
+			p.stages[i].enabled = *s.Enabled
+		}
+		if err := setFilterValues(p.stages[i].filter, s.Params); err != nil {
+			return nil, fmt.Errorf("stage %d: %v", i+1, err)
+		}
+	}
+	return p, nil
+}
+
+// stageValues returns the filter, settings, and whether it is turned on for
+// each of the stages, in the same format as the pipeline file.
+func (p *pipelineFilter) stageValues() []map[string]interface{} {
+	var stages []map[string]interface{}
+	for _, s := range p.stages {
+		stages = append(stages, map[string]interface{}{
+			"filter":  s.filter.Name(),
+			"enabled": s.enabled,
+			"params":  filterValues(s.filter),
+		})
+	}
+	return stages
+}
+
+// savePipelineFile writes the pipeline to a file, with the stages in their
+// current order along with their current settings.
+func savePipelineFile(p *pipelineFilter, file string) error {
+	v := viper.New()
+	v.Set("stages", p.stageValues())
+	if err := v.WriteConfigAs(file); err != nil {
+		return err
+	}
+	fmt.Printf("Saved pipeline to %s\n", file)
+	return nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// playbackSpeeds are the multipliers of the native FPS that video files can
+// be played at.
+var playbackSpeeds = []float64{0.25, 0.5, 1, 2, 4}
+
+// normalSpeed is the index of the native FPS in playbackSpeeds.
+const normalSpeed = 2
+
+// attachSeek creates the trackbar for seeking within a video file, which is
+// the percentage of the way through the file.
+func (r *runner) attachSeek() {
+	if !r.video.IsFile() {
+		return
+	}
+
+	r.seek = newParam("seek", 0, 100, 0)
+	r.seek.attach(r.window)
+}
+
+// nextFrame reads the next frame into img, unless the frame is frozen. When
+// the seek trackbar has been moved, the frame at the new position is read even
+// if the frame is frozen. At the end of a video file that is not looping, the
+// last frame is frozen.
+func (r *runner) nextFrame() bool {
+	if r.seek != nil && r.seek.Pos() != r.seekPos {
+		r.seekPos = r.seek.Pos()
+		r.video.Seek(r.seekPos * r.video.FrameCount() / 100)
+		return r.readFrame()
+	}
+
+	if r.freeze && !r.img.Empty() {
+		return true
+	}
+
+	if ok := r.readFrame(); ok {
+		r.updateSeek()
+		return true
+	}
+
+	if !r.video.IsFile() || r.img.Empty() {
+		return false
+	}
+
+	fmt.Printf("End of video: %v\n", videoSource)
+	r.freeze = true
+	r.window.SetWindowTitle(r.title())
+	return true
+}
+
+// readFrame reads the next frame into img. OpenCV empties the Mat when there
+// are no more frames, so the frame is read into another Mat first, and img is
+// left with the last frame at the end of a video file.
+func (r *runner) readFrame() bool {
+	next := gocv.NewMat()
+	defer next.Close()
+	if ok := r.video.Read(&next); !ok {
+		return false
+	}
+
+	next.CopyTo(&r.img)
+	r.lastFrame = time.Now()
+	return true
+}
+
+// updateSeek moves the seek trackbar to the position of the current frame.
+func (r *runner) updateSeek() {
+	if r.seek == nil {
+		return
+	}
+
+	r.seekPos = (r.video.Position() - 1) * 100 / r.video.FrameCount()
+	r.seek.SetPos(r.seekPos)
+}
+
+// wait returns how long to wait for a key in milliseconds, so that a video
+// file or a moving test pattern is played at the current speed.
+func (r *runner) wait() int {
+	fps := r.video.FPS()
+	if r.freeze || !r.video.IsTimed() || fps <= 0 {
+		return 1
+	}
+
+	interval := time.Duration(float64(time.Second) / (fps * playbackSpeeds[r.speed]))
+	delay := int((interval - time.Since(r.lastFrame)) / time.Millisecond)
+	if delay < 1 {
+		return 1
+	}
+	return delay
+}
+
+// playbackKeyBindings are the keys for controlling the playback of a video file.
+func (r *runner) playbackKeyBindings() []keyBinding {
+	return []keyBinding{
+		{comma, "step back/forward a frame in a video file", func() { r.handleStep(-1) }},
+		{period, "step back/forward a frame in a video file", func() { r.handleStep(1) }},
+		{lessThan, "slow down/speed up a video file", func() { r.handleSpeed(-1) }},
+		{greaterThan, "slow down/speed up a video file", func() { r.handleSpeed(1) }},
+		{lKey, "turn looping a video file on or off", r.handleLoop},
+	}
+}
+
+// handleStep freezes the video, and steps forward or back by a frame. At the
+// start or the end of the file, the current frame is kept.
+func (r *runner) handleStep(offset int) {
+	r.freeze = true
+	if r.video.Step(offset, &r.img) {
+		r.updateSeek()
+	}
+	r.window.SetWindowTitle(r.title())
+}
+
+func (r *runner) handleSpeed(offset int) {
+	speed := r.speed + offset
+	if speed < 0 || speed >= len(playbackSpeeds) {
+		return
+	}
+
+	r.speed = speed
+	r.window.SetWindowTitle(r.title())
+}
+
+func (r *runner) handleLoop() {
+	r.video.SetLoop(!r.video.Loop())
+	r.window.SetWindowTitle(r.title())
+}
+
+// playbackTitle describes the playback speed and looping for a video file.
+func (r *runner) playbackTitle() string {
+	var text string
+	if r.speed != normalSpeed {
+		text += fmt.Sprintf("[%gx] ", playbackSpeeds[r.speed])
+	}
+	if r.video.Loop() {
+		text += "[loop] "
+	}
+	return text
+}
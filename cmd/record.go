@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// recordViews are the choices for what is recorded to the video file.
+var recordViews = []string{"processed", "original", "side-by-side"}
+
+var recordColor = color.RGBA{0, 0, 255, 0}
+
+func validateRecordView() error {
+	for _, v := range recordViews {
+		if v == recordView {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown record view %s, must be one of processed, original, side-by-side", recordView)
+}
+
+// recorder writes frames to a video file. All of the frames are converted to
+// 8-bit BGR with the same size as the first frame, since the video writer
+// drops any other frames. The frames are paced at the frame rate of the file,
+// with written being the number of frames since the start, except for the
+// frames of a video file, where read is when the last one written was read.
+type recorder struct {
+	writer  *gocv.VideoWriter
+	file    string
+	size    image.Point
+	frame   gocv.Mat
+	fps     float64
+	start   time.Time
+	written int
+	read    time.Time
+}
+
+// newRecorder opens the video file for frames that are the same size as img.
+func newRecorder(file string, fps float64, img gocv.Mat) (*recorder, error) {
+	size := image.Pt(img.Cols(), img.Rows())
+	writer, err := gocv.VideoWriterFile(file, recordCodec, fps, size.X, size.Y, true)
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{writer: writer, file: file, size: size, frame: gocv.NewMat(), fps: fps, start: time.Now()}, nil
+}
+
+// due returns the number of times to write the current frame at the time now,
+// so that the video plays back in real time no matter how often frames are
+// displayed. Still images and frozen frames are displayed much faster than the
+// frame rate, so most of them are skipped, while slow filters repeat frames.
+func (rec *recorder) due(now time.Time) int {
+	target := int(now.Sub(rec.start).Seconds()*rec.fps) + 1
+	n := target - rec.written
+
+	// after a long pause, such as while selecting a region of the image, the
+	// video continues from the current frame instead of repeating it
+	if n > int(rec.fps) {
+		rec.written = target - 1
+		n = 1
+	}
+	return n
+}
+
+// once returns the number of times to write a frame of a video file that was
+// read at the time given, which is once for each frame that is read, so that
+// the recording has the same frames as the file at any playback speed. The
+// pacing starts again from now, for when the video is frozen.
+func (rec *recorder) once(read, now time.Time) int {
+	if read.Equal(rec.read) {
+		return 0
+	}
+
+	rec.read = read
+	rec.start, rec.written = now, 0
+	return 1
+}
+
+func (rec *recorder) write(img gocv.Mat) error {
+	toBGR(img, &rec.frame)
+	if rec.frame.Cols() != rec.size.X || rec.frame.Rows() != rec.size.Y {
+		gocv.Resize(rec.frame, &rec.frame, rec.size, 0, 0, gocv.InterpolationLinear)
+	}
+	if err := rec.writer.Write(rec.frame); err != nil {
+		return err
+	}
+	rec.written++
+	return nil
+}
+
+func (rec *recorder) close() error {
+	rec.frame.Close()
+	return rec.writer.Close()
+}
+
+// recordFileName is the name of the video file to record to, which includes
+// the time when no name has been given so that each recording is kept.
+func recordFileName(name string) string {
+	if recordFile != "" {
+		return recordFile
+	}
+	return fmt.Sprintf("%s-%s.avi", name, time.Now().Format("20060102-150405"))
+}
+
+// recordFrame is the frame to record, using the record view.
+func (r *runner) recordFrame(dst *gocv.Mat) {
+	switch recordView {
+	case "original":
+		toBGR(r.img, dst)
+	case "side-by-side":
+		compare(viewSideBySide, r.img, r.rendered, 0, dst)
+	default:
+		toBGR(r.rendered, dst)
+	}
+}
+
+// handleRecord starts or stops recording to a video file.
+func (r *runner) handleRecord() {
+	if r.recorder != nil {
+		r.stopRecording()
+		return
+	}
+
+	frame := gocv.NewMat()
+	defer frame.Close()
+	r.recordFrame(&frame)
+
+	fps := recordFPS
+	if fps <= 0 {
+		fps = r.video.FPS()
+	}
+	if fps <= 0 {
+		fps = 30
+	}
+
+	rec, err := newRecorder(recordFileName(r.filter.Name()), fps, frame)
+	if err != nil {
+		fmt.Printf("Error starting recording: %v\n", err)
+		return
+	}
+	r.recorder = rec
+	fmt.Printf("Recording to file: %v\n", rec.file)
+}
+
+func (r *runner) stopRecording() {
+	if r.recorder == nil {
+		return
+	}
+
+	if err := r.recorder.close(); err != nil {
+		fmt.Printf("Error stopping recording: %v\n", err)
+	}
+	fmt.Printf("Stopped recording to file: %v\n", r.recorder.file)
+	r.recorder = nil
+}
+
+// record writes the current frame when it is due while recording, and shows
+// the REC indicator on the displayed image. Each frame that is read from a
+// video file is written once, while frozen frames, still images, and live
+// sources are written in real time.
+func (r *runner) record() {
+	if r.recorder == nil {
+		return
+	}
+
+	var n int
+	if r.video.IsFile() && !r.freeze {
+		n = r.recorder.once(r.lastFrame, time.Now())
+	} else {
+		n = r.recorder.due(time.Now())
+	}
+
+	if n > 0 {
+		frame := gocv.NewMat()
+		defer frame.Close()
+		r.recordFrame(&frame)
+		for i := 0; i < n; i++ {
+			if err := r.recorder.write(frame); err != nil {
+				fmt.Printf("Error recording frame: %v\n", err)
+				break
+			}
+		}
+	}
+
+	toBGR(r.display, &r.display)
+	gocv.Circle(&r.display, image.Pt(20, 20), 8, recordColor, -1)
+	gocv.PutText(&r.display, "REC", image.Pt(34, 27), gocv.FontHersheySimplex, 0.7, recordColor, 2)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecorderDue(t *testing.T) {
+	start := time.Date(2020, 3, 21, 14, 25, 1, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		elapsed     time.Duration
+		written     int
+		want        int
+		wantWritten int
+	}{
+		{"first frame", 0, 0, 1, 0},
+		{"already written", 10 * time.Millisecond, 1, 0, 1},
+		{"next frame", 40 * time.Millisecond, 1, 1, 1},
+		{"slow filter", 100 * time.Millisecond, 1, 3, 1},
+		{"one second", time.Second, 31, 0, 31},
+
+		// after a long pause, only the current frame is written
+		{"long pause", 5 * time.Second, 10, 1, 150},
+	}
+
+	for _, tt := range tests {
+		rec := &recorder{fps: 30, start: start, written: tt.written}
+		if got := rec.due(start.Add(tt.elapsed)); got != tt.want || rec.written != tt.wantWritten {
+			t.Errorf("%s: due() = %d with %d written, want %d with %d written", tt.name, got, rec.written, tt.want, tt.wantWritten)
+		}
+	}
+}
+
+func TestRecorderOnce(t *testing.T) {
+	start := time.Date(2020, 3, 21, 14, 25, 1, 0, time.UTC)
+	rec := &recorder{fps: 30, start: start}
+
+	// each frame is written once, however long it is displayed for
+	frames := []struct {
+		read, now time.Duration
+		want      int
+	}{
+		{0, 0, 1},
+		{0, 10 * time.Millisecond, 0},
+		{0, time.Second, 0},
+		{time.Second, time.Second, 1},
+		{time.Second + time.Millisecond, time.Second + 2*time.Millisecond, 1},
+	}
+
+	for i, f := range frames {
+		if got := rec.once(start.Add(f.read), start.Add(f.now)); got != f.want {
+			t.Errorf("frame %d: once() = %d, want %d", i, got, f.want)
+		}
+		rec.written += f.want
+	}
+
+	// the pacing for a frozen frame carries on from the last frame written
+	now := start.Add(time.Second + 2*time.Millisecond)
+	if got := rec.due(now.Add(10 * time.Millisecond)); got != 0 {
+		t.Errorf("due() straight after a frame was written = %d, want 0", got)
+	}
+	if got := rec.due(now.Add(40 * time.Millisecond)); got != 1 {
+		t.Errorf("due() a frame after the last one was written = %d, want 1", got)
+	}
+}
@@ -18,18 +18,27 @@ import (
 	"fmt"
 	"os"
 
-	"gocv.io/x/gocv"
-
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	cfgFile     string
-	videoSource string
-	window      *gocv.Window
-	pause       bool
+	cfgFile        string
+	videoSource    string
+	headless       bool
+	outputFile     string
+	outputFrames   int
+	presetName     string
+	codeFormat     string
+	loopVideo      bool
+	recordFile     string
+	recordCodec    string
+	recordFPS      float64
+	recordView     string
+	snapshotDir    string
+	snapshotFormat string
+	snapshotSource bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -41,6 +50,7 @@ filters and algorithms for computer vision.
 
 It can also generate Go code for the current filter command using the GoCV
 programming library. CVscope is itself written using GoCV.`,
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -56,7 +66,20 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cvscope.yaml)")
-	rootCmd.PersistentFlags().StringVarP(&videoSource, "source", "f", "0", "video source, can be device number, file, or stream.")
+	rootCmd.PersistentFlags().StringVarP(&videoSource, "source", "f", "0", "video source, can be device number, file, or stream, an image file, directory, or glob, or pattern:<name> for a test pattern.")
+	rootCmd.PersistentFlags().StringVar(&presetName, "preset", "", "name of the filter preset to load from the config file, and to save to using the 'k' key.")
+	rootCmd.PersistentFlags().BoolVar(&loopVideo, "loop", false, "play a video file again from the beginning once it reaches the end, also toggled using the 'l' key.")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "video file to record to using the 'r' key (default is <command>-<time>.avi)")
+	rootCmd.PersistentFlags().StringVar(&recordCodec, "codec", "MJPG", "four character code of the codec for recording video.")
+	rootCmd.PersistentFlags().Float64Var(&recordFPS, "record-fps", 0, "frames per second for recording video (default is the FPS of the source, or 30)")
+	rootCmd.PersistentFlags().StringVar(&recordView, "record-view", "processed", "what to record: processed, original, or side-by-side.")
+	rootCmd.PersistentFlags().StringVar(&snapshotDir, "snapshot-dir", ".", "directory for the snapshots written using the 'w' key.")
+	rootCmd.PersistentFlags().StringVar(&snapshotFormat, "snapshot-format", "", "file format for snapshots, such as png or jpg (default is png for single channel images such as masks, otherwise jpg)")
+	rootCmd.PersistentFlags().BoolVar(&snapshotSource, "snapshot-source", false, "also write the source image for each snapshot.")
+	rootCmd.PersistentFlags().BoolVar(&headless, "headless", false, "run the filter without opening any windows, writing the processed images to output.")
+	rootCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "output file for headless mode, or '-' for PNG data to stdout (default is <command>.jpg)")
+	rootCmd.PersistentFlags().StringVar(&codeFormat, "code", "", "in headless mode, write code for the filter to output instead of processing images: go, go-func, go-program, python, or cpp.")
+	rootCmd.PersistentFlags().IntVar(&outputFrames, "frames", 1, "number of frames to process in headless mode, or 0 for all of them from a video file or images.")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -81,6 +104,6 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
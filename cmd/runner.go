@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"gocv.io/x/cvscope/scope"
+	"gocv.io/x/gocv"
+)
+
+// keyBinding is an action that is performed when a key is pressed.
+type keyBinding struct {
+	key    int
+	desc   string
+	action func()
+}
+
+// runner displays the results of applying a filter to a video source.
+type runner struct {
+	filter    Filter
+	video     *scope.Source
+	window    *gocv.Window
+	img       gocv.Mat
+	processed gocv.Mat
+	rendered  gocv.Mat
+	display   gocv.Mat
+	freeze    bool
+	original  bool
+	done      bool
+	keys      []keyBinding
+
+	// view is how the original and processed images are displayed, with split
+	// controlling the position of the split line. The split trackbar is only
+	// created once the split view is first used.
+	view  viewMode
+	split *Param
+
+	// seek is the trackbar for the position in a video file, with seekPos
+	// being its position when it was last updated. The video is played at the
+	// playback speed, timed from when the last frame was read.
+	seek      *Param
+	seekPos   int
+	speed     int
+	lastFrame time.Time
+
+	// recorder is writing frames to a video file, when recording.
+	recorder *recorder
+
+	// overlay is set when the overlay with the parameter values and timings is
+	// shown, with processTime being how long the filter took for the current
+	// frame, and fps the rate that frames are displayed.
+	overlay     bool
+	processTime time.Duration
+	fps         float64
+	lastLoop    time.Time
+
+	// help is set when the help for the keys is shown.
+	help bool
+
+	// zoom is the index of the zoom level, with center being the pixel in the
+	// center of the viewport. mouse is the position of the mouse in the
+	// displayed image, which is over the pixel being inspected when inspect is
+	// set, or -1, -1 until the mouse is moved over the window.
+	zoom    int
+	center  image.Point
+	mouse   image.Point
+	inspect bool
+
+	// histogram is the window with the histograms, when it is shown.
+	histogram *gocv.Window
+
+	// windowName is the name that the window was created with, and shownTitle
+	// is the title that was last set for the window.
+	windowName string
+	shownTitle string
+}
+
+// runFilter opens the video source and interactively runs the filter until
+// the user exits.
+func runFilter(f Filter) {
+	video, err := scope.OpenVideoCapture(videoSource)
+	if err != nil {
+		fmt.Printf("Error opening video: %v\n", err)
+		return
+	}
+	defer video.Close()
+
+	if err := validateRecordView(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	video.SetLoop(loopVideo)
+
+	// the center is moved to the center of the first frame that is displayed
+	r := &runner{filter: f, video: video, split: newParam("split", 0, 100, 50), speed: normalSpeed,
+		center: image.Pt(-1, -1), mouse: image.Pt(-1, -1)}
+
+	r.windowName = r.title()
+	r.window = gocv.NewWindow(r.windowName)
+	defer r.window.Close()
+	setMouseHandler(r.windowName, r.handleMouse)
+
+	for _, p := range f.Params() {
+		p.attach(r.window)
+	}
+	r.attachSeek()
+
+	r.img = gocv.NewMat()
+	defer r.img.Close()
+
+	r.processed = gocv.NewMat()
+	defer r.processed.Close()
+
+	r.rendered = gocv.NewMat()
+	defer r.rendered.Close()
+
+	r.display = gocv.NewMat()
+	defer r.display.Close()
+
+	defer r.stopRecording()
+	defer r.closeHistogram()
+
+	r.keys = r.keyBindings()
+
+	fmt.Printf("Start reading video: %v\n", videoSource)
+
+	for !r.done {
+		if ok := r.nextFrame(); !ok {
+			fmt.Printf("Device closed: %v\n", videoSource)
+			return
+		}
+		if r.img.Empty() {
+			continue
+		}
+
+		// make sure we do not have any invalid values
+		f.Validate()
+
+		start := time.Now()
+		f.Process(r.img, &r.processed)
+		r.processTime = time.Since(start)
+		render(f, r.img, r.processed, &r.rendered)
+		r.refreshTitle()
+
+		r.compose(&r.display)
+		r.drawInspector()
+		r.record()
+		r.drawOverlay()
+		r.drawHelp()
+		r.window.IMShow(r.display)
+		r.showHistograms()
+		r.updateFPS()
+
+		// Check to see if the user has pressed any keys on the keyboard
+		r.handleKey(r.window.WaitKey(r.wait()))
+	}
+}
+
+// compose puts together the image to display, using the view mode and the
+// zoom, without anything drawn over it.
+func (r *runner) compose(dst *gocv.Mat) {
+	// Display the processed image?
+	switch {
+	case r.original:
+		r.img.CopyTo(dst)
+	case r.view != viewProcessed:
+		compare(r.view, r.img, r.rendered, r.split.Pos(), dst)
+	default:
+		r.rendered.CopyTo(dst)
+	}
+
+	r.zoomImage(dst)
+}
+
+// keyHandler is implemented by filters that handle keys of their own, in
+// addition to the keys used to page through their enums.
+type keyHandler interface {
+	keyBindings() []keyBinding
+}
+
+// sampler is implemented by filters that can set their parameters from the
+// pixels in a region of the image.
+type sampler interface {
+	sample(img gocv.Mat, region image.Rectangle)
+}
+
+// keyBindings returns the keys that are handled for the current filter. The
+// keys of the filter come first, and take the place of any common keys that
+// are the same. When there is no video source, such as when generating the
+// help for a command, the keys for every kind of source are included.
+func (r *runner) keyBindings() []keyBinding {
+	var keys []keyBinding
+	if h, ok := r.filter.(keyHandler); ok {
+		for _, k := range h.keyBindings() {
+			action := k.action
+			keys = append(keys, keyBinding{k.key, k.desc, func() { action(); r.window.SetWindowTitle(r.title()) }})
+		}
+	}
+
+	var common []keyBinding
+	if r.video == nil || r.video.Count() > 0 {
+		common = append(common,
+			keyBinding{nKey, "show the next/previous image from a directory or glob", func() { r.handleChangeImage(r.video.Next) }},
+			keyBinding{bKey, "show the next/previous image from a directory or glob", func() { r.handleChangeImage(r.video.Prev) }},
+		)
+	}
+	if r.video == nil || r.video.IsFile() {
+		common = append(common, r.playbackKeyBindings()...)
+	}
+	if _, ok := r.filter.(sampler); ok {
+		common = append(common, keyBinding{uKey, "select a region of the image using the mouse to set the ranges from", r.handleSample})
+	}
+
+	common = append(common,
+		keyBinding{space, "freeze/unfreeze the current frame, while still applying the filter", r.handleFreeze},
+		keyBinding{oKey, "show the original image instead of the filtered one", r.handleOriginal},
+		keyBinding{mKey, "page through the filtered, side by side, and split views", r.handleViewMode},
+		keyBinding{iKey, "show/hide the overlay with the parameter values and timings", r.handleOverlay},
+		keyBinding{plus, "zoom in/out", func() { r.handleZoom(1) }},
+		keyBinding{equals, "zoom in/out", func() { r.handleZoom(1) }},
+		keyBinding{minus, "zoom in/out", func() { r.handleZoom(-1) }},
+		keyBinding{upperIKey, "pan up/left/down/right", func() { r.handlePan(0, -1) }},
+		keyBinding{upperJKey, "pan up/left/down/right", func() { r.handlePan(-1, 0) }},
+		keyBinding{upperKKey, "pan up/left/down/right", func() { r.handlePan(0, 1) }},
+		keyBinding{upperLKey, "pan up/left/down/right", func() { r.handlePan(1, 0) }},
+		keyBinding{eKey, "turn the pixel inspector for the pixel under the mouse on or off", r.handleInspect},
+		keyBinding{yKey, "show/hide the histograms of the input and processed images", r.handleHistogram},
+		keyBinding{hKey, "show/hide this help", r.handleHelp},
+		keyBinding{questionMark, "show/hide this help", r.handleHelp},
+		keyBinding{wKey, "write a snapshot of the displayed image", r.handleSnapshot},
+		keyBinding{upperWKey, "write a snapshot of the raw processed image as TIFF", r.handleRawSnapshot},
+		keyBinding{rKey, "start/stop recording video to a file", r.handleRecord},
+		keyBinding{kKey, "save the settings as a preset", r.handleSavePreset},
+		keyBinding{gKey, "print Go code for the current settings", func() { printCode("Go", r.filter.GoCode("src", "dest", r.channels())) }},
+		keyBinding{upperGKey, "write a Go program for the current settings", r.handleWriteGoProgram},
+		keyBinding{pKey, "print Python code for the current settings", func() { printCode("Python", r.filter.PythonCode("src", "dest", r.channels())) }},
+		keyBinding{cKey, "print C++ code for the current settings", func() { printCode("C++", r.filter.CppCode("src", "dest", r.channels())) }},
+		keyBinding{esc, "exit", func() { r.done = true }},
+	)
+
+	for _, k := range common {
+		if !hasKey(keys, k.key) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func hasKey(keys []keyBinding, key int) bool {
+	for _, k := range keys {
+		if k.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *runner) handleKey(key int) {
+	// the enums are checked every time, since the current enums can change
+	for _, e := range r.filter.Enums() {
+		switch key {
+		case e.PrevKey:
+			e.Prev()
+			r.window.SetWindowTitle(r.title())
+			return
+		case e.NextKey:
+			e.Next()
+			r.window.SetWindowTitle(r.title())
+			return
+		}
+	}
+
+	for _, k := range r.keys {
+		if k.key == key {
+			k.action()
+			return
+		}
+	}
+}
+
+// title is the window title, which shows when the frame is frozen and the
+// current view mode along with the filter settings.
+func (r *runner) title() string {
+	text := r.filter.Title()
+	if r.view != viewProcessed {
+		text = "[" + r.view.String() + "] " + text
+	}
+	if r.video.IsFile() {
+		text = r.playbackTitle() + text
+	}
+	if r.video.Count() > 0 {
+		text = fmt.Sprintf("[%d/%d %s] %s", r.video.Index()+1, r.video.Count(), r.video.FileName(), text)
+	}
+	if r.original {
+		text = "[original] " + text
+	}
+	if r.freeze {
+		text = "**FROZEN** " + text
+	}
+	return text
+}
+
+// channels is the number of channels of the images from the video source, for
+// the generated code.
+func (r *runner) channels() int {
+	if r.img.Empty() {
+		return bgrChannels
+	}
+	return r.img.Channels()
+}
+
+// refreshTitle updates the window title when it has changed, such as when the
+// filter has computed a new threshold for the frame.
+func (r *runner) refreshTitle() {
+	if title := r.title(); title != r.shownTitle {
+		r.window.SetWindowTitle(title)
+		r.shownTitle = title
+	}
+}
+
+// handleChangeImage changes to another image file, keeping the current
+// filter settings.
+func (r *runner) handleChangeImage(change func() error) {
+	if err := change(); err != nil {
+		fmt.Printf("Error reading image: %v\n", err)
+	}
+	r.window.SetWindowTitle(r.title())
+}
+
+// handleFreeze holds the current frame, so that the filter settings can be
+// tuned on it while the filter continues to be applied.
+func (r *runner) handleFreeze() {
+	r.freeze = !r.freeze
+	r.window.SetWindowTitle(r.title())
+}
+
+// handleOriginal shows the original image instead of the filtered one.
+func (r *runner) handleOriginal() {
+	r.original = !r.original
+	r.window.SetWindowTitle(r.title())
+}
+
+// handleViewMode pages through the view modes, creating the split trackbar
+// the first time the split view is used.
+func (r *runner) handleViewMode() {
+	r.view = (r.view + 1) % viewModes
+	if r.view == viewSplit && r.split.tracker == nil {
+		r.split.attach(r.window)
+	}
+	r.window.SetWindowTitle(r.title())
+}
+
+// handleSample lets the user select a region of the original image using the
+// mouse, and then sets the filter parameters from the pixels in the region.
+func (r *runner) handleSample() {
+	region := r.window.SelectROI(r.img)
+
+	// selecting the region takes over the mouse for the window
+	setMouseHandler(r.windowName, r.handleMouse)
+
+	if region.Empty() {
+		return
+	}
+	r.filter.(sampler).sample(r.img, region)
+	r.window.SetWindowTitle(r.title())
+}
+
+func (r *runner) handleSavePreset() {
+	if err := savePreset(r.filter); err != nil {
+		fmt.Printf("Error saving preset: %v\n", err)
+	}
+}
+
+func (r *runner) handleWriteGoProgram() {
+	if err := writeGoProgram(r.filter, "cvscope-"+r.filter.Name()); err != nil {
+		fmt.Printf("Error writing Go program: %v\n", err)
+	}
+}
@@ -4,138 +4,84 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(scharrCmd)
+	registerFilter(scharrCmd, newScharrFilter)
 }
 
-var currentScharrBorder int
-var scharrDXTracker, scharrDYTracker, scharrScaleTracker, scharrDeltaTracker *gocv.Trackbar
-var scharrDX, scharrDY int
-var scharrScale, scharrDelta float64
-
 var scharrCmd = &cobra.Command{
 	Use:   "scharr",
 	Short: "Apply Scharr to video images",
-	Long: `Apply Scharr to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through border calculation types.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleScharrCmd()
-	},
+	Long:  `Apply Scharr to video images.`,
 }
 
-func handleScharrCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(scharrWindowTitle())
-	defer window.Close()
-
-	scharrDXTracker = window.CreateTrackbar("dx", 1)
-	scharrDXTracker.SetPos(1)
-
-	scharrDYTracker = window.CreateTrackbar("dy", 1)
-	scharrDYTracker.SetPos(0)
-
-	scharrScaleTracker = window.CreateTrackbar("scale", 60)
-	scharrScaleTracker.SetPos(30)
-
-	scharrDeltaTracker = window.CreateTrackbar("delta", 60)
-	scharrDeltaTracker.SetPos(30)
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// make sure we do not have any invalid values
-		validateScharrTrackers()
-
-		// scharr image processing filter
-		gocv.Scharr(img, &processed, gocv.MatTypeCV16S, scharrDX, scharrDY, scharrScale, scharrDelta, getCurrentBorder(currentScharrBorder))
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentScharrBorder = prevBorder(currentScharrBorder)
-			window.SetWindowTitle(scharrWindowTitle())
-		case xKey:
-			currentScharrBorder = nextBorder(currentScharrBorder)
-			window.SetWindowTitle(scharrWindowTitle())
-		case gKey:
-			scharrGoCodeFragment(scharrDX, scharrDY, scharrScale, scharrDelta, getCurrentBorderDescription(currentScharrBorder))
-		case pKey:
-			scharrPythonCodeFragment(scharrDX, scharrDY, scharrScale, scharrDelta, currentScharrBorder)
-		case space:
-			handlePause(scharrWindowTitle())
-		case wKey:
-			writeFile("scharr", processed)
-		case esc:
-			return
-		}
-	}
+type scharrFilter struct {
+	dx, dy, scale, delta *Param
+	border, mode         *Enum
 }
 
-// either ksize or sigmax have to be non-zero
-func validateScharrTrackers() {
-	switch {
-	case scharrDXTracker.GetPos() == 1:
-		scharrDYTracker.SetPos(0)
-	case scharrDYTracker.GetPos() == 1:
-		scharrDXTracker.SetPos(0)
-	case scharrDXTracker.GetPos() == 0 && scharrDYTracker.GetPos() == 0:
-		scharrDYTracker.SetPos(1)
+func newScharrFilter() Filter {
+	return &scharrFilter{
+		dx:     newParam("dx", 0, 1, 1),
+		dy:     newParam("dy", 0, 1, 0),
+		scale:  newParam("scale", 0, 60, 30),
+		delta:  newParam("delta", 0, 60, 30),
+		border: newBorderEnum(),
+		mode:   newDisplayEnum(true),
 	}
+}
+
+func (f *scharrFilter) Name() string {
+	return "scharr"
+}
+
+func (f *scharrFilter) Title() string {
+	return "Scharr - " + f.border.Description() + " - " + f.mode.Description() + " - CVscope"
+}
+
+func (f *scharrFilter) Params() []*Param {
+	return []*Param{f.dx, f.dy, f.scale, f.delta}
+}
+
+func (f *scharrFilter) Enums() []*Enum {
+	return []*Enum{f.border, f.mode}
+}
+
+// only one of dx or dy can be set
+func (f *scharrFilter) Validate() {
+	validateDerivative(f.dx, f.dy)
+}
+
+func (f *scharrFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.Scharr(src, dst, gocv.MatTypeCV16S, f.dx.Pos(), f.dy.Pos(),
+		float64(f.scale.Pos()), float64(f.delta.Pos()), gocv.BorderType(f.border.Value()))
+}
+
+// the output is CV16S
+func (f *scharrFilter) signedOutput() bool {
+	return true
+}
 
-	scharrDX = scharrDXTracker.GetPos()
-	scharrDY = scharrDYTracker.GetPos()
-	scharrScale = float64(scharrScaleTracker.GetPos())
-	scharrDelta = float64(scharrDeltaTracker.GetPos())
+// display renders the output using the current display mode.
+func (f *scharrFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	displayDerivative(f.mode, src, processed, dst, func(gray gocv.Mat, dx, dy int, dst *gocv.Mat) {
+		gocv.Scharr(gray, dst, gocv.MatTypeCV32F, dx, dy, 1, 0, gocv.BorderType(f.border.Value()))
+	})
 }
 
-func scharrWindowTitle() string {
-	return "Scharr - " + getCurrentBorderDescription(currentScharrBorder) + " - CVscope"
+func (f *scharrFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.Scharr(%s, &%s, gocv.MatTypeCV16S, %d, %d, %1.f, %1.f, gocv.%s)",
+		src, dst, f.dx.Pos(), f.dy.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Description())
 }
 
-func scharrGoCodeFragment(dx, dy int, sx, sy float64, borderType string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\ngocv.Scharr(src, &dest, gocv.MatTypeCV16S, %d, %d, %1.f, %1.f, gocv.%s)\n\n",
-		dx, dy, sx, sy, borderType)
+func (f *scharrFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.Scharr(%s, cv.CV_16S, %d, %d, scale=%1.f, delta=%1.f, borderType=cv.%s)",
+		dst, src, f.dx.Pos(), f.dy.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.OpenCV())
 }
 
-func scharrPythonCodeFragment(dx, dy int, sx, sy float64, borderType int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+func (f *scharrFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::Scharr(%s, %s, CV_16S, %d, %d, %1.f, %1.f, %s);",
+		src, dst, f.dx.Pos(), f.dy.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Cpp())
 }
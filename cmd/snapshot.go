@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// snapshotInfo is the JSON sidecar file that is written along with each
+// snapshot, describing how the snapshot was made.
+type snapshotInfo struct {
+	Filter      string                   `json:"filter"`
+	Params      map[string]interface{}   `json:"params,omitempty"`
+	Stages      []map[string]interface{} `json:"stages,omitempty"`
+	Source      string                   `json:"source"`
+	SourceFile  string                   `json:"source-file,omitempty"`
+	Frame       *int                     `json:"frame,omitempty"`
+	Image       string                   `json:"image"`
+	SourceImage string                   `json:"source-image,omitempty"`
+	Time        string                   `json:"time"`
+}
+
+// maskFilter is implemented by filters whose output is a mask, such as the
+// threshold filters, which would be spoiled by lossy compression.
+type maskFilter interface {
+	maskOutput() bool
+}
+
+// snapshotExt is the file extension for a snapshot of the image. Unless a
+// format has been chosen, single channel images and the output of filters
+// that produce masks are written as lossless PNG files, and other images as
+// JPG files. The threshold of a color image is a mask with three channels, so
+// the number of channels is not enough to tell.
+func snapshotExt(img gocv.Mat, mask bool) string {
+	if snapshotFormat != "" {
+		return "." + strings.TrimPrefix(strings.ToLower(snapshotFormat), ".")
+	}
+	if mask || img.Channels() == 1 {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// isMask reports whether the output of the filter is a mask.
+func isMask(f Filter) bool {
+	m, ok := f.(maskFilter)
+	return ok && m.maskOutput()
+}
+
+// snapshotBase returns the path for the files of a new snapshot, without any
+// extension. The name includes the time, along with a number when there is
+// already a snapshot with the same name.
+func snapshotBase(name string) string {
+	base := filepath.Join(snapshotDir, name+"-"+time.Now().Format("20060102-150405"))
+	unique := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(unique + ".json"); os.IsNotExist(err) {
+			return unique
+		}
+		unique = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// writeSnapshot writes the image to a new file in the snapshot directory using
+// the file extension, along with the source frame when chosen, and a JSON
+// sidecar file with the filter settings and the source.
+func (r *runner) writeSnapshot(img gocv.Mat, ext string) error {
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return err
+	}
+
+	base := snapshotBase(r.filter.Name())
+	info := snapshotInfo{
+		Filter: r.filter.Name(),
+		Source: videoSource,
+		Image:  filepath.Base(base + ext),
+		Time:   time.Now().Format(time.RFC3339),
+	}
+
+	if p, ok := r.filter.(*pipelineFilter); ok {
+		info.Stages = p.stageValues()
+	} else {
+		info.Params = filterValues(r.filter)
+	}
+
+	if r.video.Count() > 0 {
+		info.SourceFile = r.video.FileName()
+	}
+	if r.video.IsFile() {
+		frame := r.video.Position() - 1
+		info.Frame = &frame
+	}
+
+	if ok := gocv.IMWrite(base+ext, img); !ok {
+		return fmt.Errorf("error writing file: %v", base+ext)
+	}
+
+	if snapshotSource {
+		file := base + "-source" + snapshotExt(r.img, false)
+		if ok := gocv.IMWrite(file, r.img); !ok {
+			return fmt.Errorf("error writing file: %v", file)
+		}
+		info.SourceImage = filepath.Base(file)
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(base+".json", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote snapshot: %v\n", base+ext)
+	return nil
+}
+
+// handleSnapshot writes the image as it is displayed, using the current view
+// and zoom, but without the overlay, the help, or the REC indicator.
+func (r *runner) handleSnapshot() {
+	img := gocv.NewMat()
+	defer img.Close()
+	r.compose(&img)
+
+	mask := isMask(r.filter) && !r.original
+	if err := r.writeSnapshot(img, snapshotExt(img, mask)); err != nil {
+		fmt.Printf("Error writing snapshot: %v\n", err)
+	}
+}
+
+// handleRawSnapshot writes the processed image as a TIFF file, which keeps
+// the 16-bit data from the derivative filters as-is.
+func (r *runner) handleRawSnapshot() {
+	if err := r.writeSnapshot(r.processed, ".tiff"); err != nil {
+		fmt.Printf("Error writing snapshot: %v\n", err)
+	}
+}
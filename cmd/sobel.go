@@ -4,142 +4,98 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(sobelCmd)
+	registerFilter(sobelCmd, newSobelFilter)
 }
 
-var currentSobelBorder int
-var sobelDXTracker, sobelDYTracker, sobelKSizeTracker, sobelScaleTracker, sobelDeltaTracker *gocv.Trackbar
-var sobelDX, sobelDY, sobelKSize int
-var sobelScale, sobelDelta float64
-
 var sobelCmd = &cobra.Command{
 	Use:   "sobel",
 	Short: "Apply Sobel to video images",
-	Long: `Apply Sobel to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through border calculation types.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleSobelCmd()
-	},
-}
-
-func handleSobelCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
-	}
-	defer video.Close()
-
-	window = gocv.NewWindow(sobelWindowTitle())
-	defer window.Close()
-
-	sobelDXTracker = window.CreateTrackbar("dx", 1)
-	sobelDXTracker.SetPos(1)
-
-	sobelDYTracker = window.CreateTrackbar("dy", 1)
-	sobelDYTracker.SetPos(0)
-
-	sobelKSizeTracker = window.CreateTrackbar("ksize", 7)
-	sobelKSizeTracker.SetPos(3)
-
-	sobelScaleTracker = window.CreateTrackbar("scale", 60)
-	sobelScaleTracker.SetPos(30)
-
-	sobelDeltaTracker = window.CreateTrackbar("delta", 60)
-	sobelDeltaTracker.SetPos(30)
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// make sure we do not have any invalid values
-		validateSobelTrackers()
-
-		// sobel image processing filter
-		gocv.Sobel(img, &processed, gocv.MatTypeCV16S, sobelDX, sobelDY, sobelKSize, sobelScale, sobelDelta, getCurrentBorder(currentSobelBorder))
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			currentSobelBorder = prevBorder(currentSobelBorder)
-			window.SetWindowTitle(sobelWindowTitle())
-		case xKey:
-			currentSobelBorder = nextBorder(currentSobelBorder)
-			window.SetWindowTitle(sobelWindowTitle())
-		case gKey:
-			sobelGoCodeFragment(sobelDX, sobelDY, sobelKSize, sobelScale, sobelDelta, getCurrentBorderDescription(currentSobelBorder))
-		case pKey:
-			sobelPythonCodeFragment(sobelDX, sobelDY, sobelKSize, sobelScale, sobelDelta, currentSobelBorder)
-		case space:
-			handlePause(sobelWindowTitle())
-		case wKey:
-			writeFile("sobel", processed)
-		case esc:
-			return
-		}
-	}
+	Long:  `Apply Sobel to video images.`,
 }
 
-// either ksize or sigmax have to be non-zero
-func validateSobelTrackers() {
-	switch {
-	case sobelDXTracker.GetPos() == 1:
-		sobelDYTracker.SetPos(0)
-	case sobelDYTracker.GetPos() == 1:
-		sobelDXTracker.SetPos(0)
-	case sobelDXTracker.GetPos() == 0 && sobelDYTracker.GetPos() == 0:
-		sobelDYTracker.SetPos(1)
+type sobelFilter struct {
+	dx, dy, ksize, scale, delta *Param
+	border, mode                *Enum
+}
+
+func newSobelFilter() Filter {
+	return &sobelFilter{
+		dx:     newParam("dx", 0, 1, 1),
+		dy:     newParam("dy", 0, 1, 0),
+		ksize:  newParam("ksize", 0, 7, 3),
+		scale:  newParam("scale", 0, 60, 30),
+		delta:  newParam("delta", 0, 60, 30),
+		border: newBorderEnum(),
+		mode:   newDisplayEnum(true),
 	}
+}
+
+func (f *sobelFilter) Name() string {
+	return "sobel"
+}
+
+func (f *sobelFilter) Title() string {
+	return "Sobel - " + f.border.Description() + " - " + f.mode.Description() + " - CVscope"
+}
+
+func (f *sobelFilter) Params() []*Param {
+	return []*Param{f.dx, f.dy, f.ksize, f.scale, f.delta}
+}
 
-	sobelDX = sobelDXTracker.GetPos()
-	sobelDY = sobelDYTracker.GetPos()
-	sobelKSize = ensureOdd(sobelKSizeTracker)
-	sobelScale = float64(sobelScaleTracker.GetPos())
-	sobelDelta = float64(sobelDeltaTracker.GetPos())
+func (f *sobelFilter) Enums() []*Enum {
+	return []*Enum{f.border, f.mode}
 }
 
-func sobelWindowTitle() string {
-	return "Sobel - " + getCurrentBorderDescription(currentSobelBorder) + " - CVscope"
+// only one of dx or dy can be set, and ksize has to be odd
+func (f *sobelFilter) Validate() {
+	validateDerivative(f.dx, f.dy)
+	ensureOdd(f.ksize)
 }
 
-func sobelGoCodeFragment(dx, dy, k int, sx, sy float64, borderType string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\ngocv.Sobel(src, &dest, gocv.MatTypeCV16S, %d, %d, %d, %1.f, %1.f, gocv.%s)\n\n",
-		dx, dy, k, sx, sy, borderType)
+func (f *sobelFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	gocv.Sobel(src, dst, gocv.MatTypeCV16S, f.dx.Pos(), f.dy.Pos(), f.ksize.Pos(),
+		float64(f.scale.Pos()), float64(f.delta.Pos()), gocv.BorderType(f.border.Value()))
 }
 
-func sobelPythonCodeFragment(dx, dy, k int, sx, sy float64, borderType int) {
-	codeFragmentHeader("Python")
-	fmt.Println("Not implemented.")
+// the output is CV16S
+func (f *sobelFilter) signedOutput() bool {
+	return true
+}
+
+// display renders the output using the current display mode.
+func (f *sobelFilter) display(src, processed gocv.Mat, dst *gocv.Mat) {
+	displayDerivative(f.mode, src, processed, dst, func(gray gocv.Mat, dx, dy int, dst *gocv.Mat) {
+		gocv.Sobel(gray, dst, gocv.MatTypeCV32F, dx, dy, f.ksize.Pos(), 1, 0, gocv.BorderType(f.border.Value()))
+	})
+}
+
+func (f *sobelFilter) GoCode(src, dst string, channels int) string {
+	return fmt.Sprintf("gocv.Sobel(%s, &%s, gocv.MatTypeCV16S, %d, %d, %d, %1.f, %1.f, gocv.%s)",
+		src, dst, f.dx.Pos(), f.dy.Pos(), f.ksize.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Description())
+}
+
+func (f *sobelFilter) PythonCode(src, dst string, channels int) string {
+	return fmt.Sprintf("%s = cv.Sobel(%s, cv.CV_16S, %d, %d, ksize=%d, scale=%1.f, delta=%1.f, borderType=cv.%s)",
+		dst, src, f.dx.Pos(), f.dy.Pos(), f.ksize.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.OpenCV())
+}
+
+func (f *sobelFilter) CppCode(src, dst string, channels int) string {
+	return fmt.Sprintf("cv::Sobel(%s, %s, CV_16S, %d, %d, %d, %1.f, %1.f, %s);",
+		src, dst, f.dx.Pos(), f.dy.Pos(), f.ksize.Pos(), float64(f.scale.Pos()), float64(f.delta.Pos()), f.border.Cpp())
+}
+
+// validateDerivative makes sure that exactly one of dx or dy is set.
+func validateDerivative(dx, dy *Param) {
+	switch {
+	case dx.Pos() == 1:
+		dy.SetPos(0)
+	case dy.Pos() == 1:
+		dx.SetPos(0)
+	case dx.Pos() == 0 && dy.Pos() == 0:
+		dy.SetPos(1)
+	}
 }
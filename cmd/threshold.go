@@ -4,137 +4,139 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"gocv.io/x/cvscope/scope"
 	"gocv.io/x/gocv"
 )
 
 func init() {
-	rootCmd.AddCommand(thresholdCmd)
+	registerFilter(thresholdCmd, newThresholdFilter)
 }
 
-var currentThreshold int
-
 var thresholdCmd = &cobra.Command{
 	Use:   "threshold",
 	Short: "Apply threshold filter to video images",
-	Long: `Apply threshold filter to video images.
-
-Key commands:
-  Use 'z' and 'x' keys to page through threshold calculation types.
-  Press 'esc' to exit.
-  Press 'space' to pause/resume filtering.
-  Press 'g' to generate Go code based on the current filter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		handleThresholdCmd()
-	},
-}
-
-func handleThresholdCmd() {
-	video, err := scope.OpenVideoCapture(videoSource)
-	if err != nil {
-		fmt.Printf("Error opening video: %v\n", err)
-		return
+	Long:  `Apply threshold filter to video images.`,
+}
+
+type thresholdFilter struct {
+	threshold *Param
+	typ       *Enum
+	auto      *Enum
+
+	// computed is the threshold that was returned by gocv.Threshold for the
+	// current frame, which is computed from the image by the auto modes.
+	computed float32
+}
+
+func newThresholdFilter() Filter {
+	return &thresholdFilter{
+		threshold: newParam("threshold", 0, 255, 128),
+		typ:       newThresholdEnum(zKey, xKey, 5),
+		auto: &Enum{
+			Name:    "auto threshold",
+			PrevKey: upperFKey,
+			NextKey: fKey,
+			Options: []Option{
+				{"None", 0, ""},
+				{"ThresholdOtsu", int(gocv.ThresholdOtsu), "THRESH_OTSU"},
+				{"ThresholdTriangle", int(gocv.ThresholdTriangle), "THRESH_TRIANGLE"},
+			},
+		},
 	}
-	defer video.Close()
-
-	window = gocv.NewWindow(thresholdWindowTitle())
-	defer window.Close()
-
-	tracker := window.CreateTrackbar("threshold", 255)
-	tracker.SetPos(128)
-
-	img := gocv.NewMat()
-	defer img.Close()
-
-	processed := gocv.NewMat()
-	defer processed.Close()
-
-	fmt.Printf("Start reading video: %v\n", videoSource)
-
-	for {
-		if ok := video.Read(&img); !ok {
-			fmt.Printf("Device closed: %v\n", videoSource)
-			return
-		}
-		if img.Empty() {
-			continue
-		}
-
-		// Threshold image processing filter
-		gocv.Threshold(img, &processed, float32(tracker.GetPos()), 255.0, getCurrentThreshold())
-
-		// Display the processed image?
-		if pause {
-			window.IMShow(img)
-		} else {
-			window.IMShow(processed)
-		}
-
-		// Check to see if the user has pressed any keys on the keyboard
-		key := window.WaitKey(1)
-		switch key {
-		case zKey:
-			prevThreshold()
-			window.SetWindowTitle(thresholdWindowTitle())
-		case xKey:
-			nextThreshold()
-			window.SetWindowTitle(thresholdWindowTitle())
-		case gKey:
-			thresholdGoCodeFragment(tracker.GetPos(), getCurrentThresholdDescription())
-		case pKey:
-			thresholdPythonCodeFragment(tracker.GetPos(), currentThreshold)
-		case space:
-			handlePause(thresholdWindowTitle())
-		case wKey:
-			writeFile("threshold", processed)
-		case esc:
-			return
-		}
+}
+
+func (f *thresholdFilter) Name() string {
+	return "threshold"
+}
+
+func (f *thresholdFilter) Title() string {
+	if f.isAuto() {
+		return fmt.Sprintf("Threshold - %s+%s (%.0f) - CVscope", f.typ.Description(), f.auto.Description(), f.computed)
 	}
+	return "Threshold - " + f.typ.Description() + " - CVscope"
+}
+
+func (f *thresholdFilter) Params() []*Param {
+	return []*Param{f.threshold}
+}
+
+func (f *thresholdFilter) Enums() []*Enum {
+	return []*Enum{f.typ, f.auto}
 }
 
-func getCurrentThreshold() gocv.ThresholdType {
-	return gocv.ThresholdType(currentThreshold)
+func (f *thresholdFilter) Validate() {}
+
+// isAuto returns true when the threshold is computed from the image using
+// Otsu's method or the triangle method, instead of using the slider.
+func (f *thresholdFilter) isAuto() bool {
+	return f.auto.Value() != 0
 }
 
-func getCurrentThresholdDescription() string {
-	switch currentThreshold {
-	case 0:
-		return "ThresholdBinary"
-	case 1:
-		return "ThresholdBinaryInv"
-	case 2:
-		return "ThresholdTrunc"
-	case 3:
-		return "ThresholdToZero"
-	case 4:
-		return "ThresholdToZeroInv"
+// the slider is ignored when the threshold is computed
+func (f *thresholdFilter) paramValue(p *Param) (string, bool) {
+	if p == f.threshold && f.isAuto() {
+		return fmt.Sprintf("%.0f (%s)", f.computed, f.auto.Description()), true
 	}
+	return "", false
+}
 
-	return "Unknown"
+func (f *thresholdFilter) histogramMarkers() []marker {
+	return []marker{{float64(f.computed), allChannels}}
 }
 
-func prevThreshold() {
-	currentThreshold--
-	if currentThreshold < 0 {
-		currentThreshold = 4
+// the auto modes convert the input to grayscale
+func (f *thresholdFilter) outputChannels(input int) int {
+	if f.isAuto() {
+		return 1
 	}
+	return input
 }
 
-func nextThreshold() {
-	currentThreshold = (currentThreshold + 1) % 5
+// the output is a mask, or close to one for the truncating types
+func (f *thresholdFilter) maskOutput() bool {
+	return true
 }
 
-func thresholdWindowTitle() string {
-	return "Threshold - " + getCurrentThresholdDescription() + " - CVscope"
+func (f *thresholdFilter) Process(src gocv.Mat, dst *gocv.Mat) {
+	if !f.isAuto() {
+		f.computed = gocv.Threshold(src, dst, float32(f.threshold.Pos()), 255.0, gocv.ThresholdType(f.typ.Value()))
+		return
+	}
+
+	gray := gocv.NewMat()
+	defer gray.Close()
+
+	// the auto modes only work on grayscale images
+	toGray(src, &gray)
+
+	f.computed = gocv.Threshold(gray, dst, 0, 255.0, gocv.ThresholdType(f.typ.Value()|f.auto.Value()))
 }
 
-func thresholdGoCodeFragment(pos int, threshType string) {
-	codeFragmentHeader("Go")
-	fmt.Printf("\ngocv.Threshold(src, &dest, %.1f, 255.0, gocv.%s)\n\n", float32(pos), threshType)
+func (f *thresholdFilter) GoCode(src, dst string, channels int) string {
+	if !f.isAuto() {
+		return fmt.Sprintf("gocv.Threshold(%s, &%s, %.1f, 255.0, gocv.%s)", src, dst, float32(f.threshold.Pos()), f.typ.Description())
+	}
+
+	gray, code := grayGoCode(src, dst, channels)
+	return code + fmt.Sprintf("%sThresh := gocv.Threshold(%s, &%s, 0.0, 255.0, gocv.%s|gocv.%s)\n_ = %sThresh // the computed threshold",
+		dst, gray, dst, f.typ.Description(), f.auto.Description(), dst)
 }
 
-func thresholdPythonCodeFragment(pos int, threshType int) {
-	codeFragmentHeader("Python")
-	fmt.Printf("\nretval, dest = cv.threshold(src, %.1f, 255.0, %d)\n\n", float32(pos), threshType)
+func (f *thresholdFilter) PythonCode(src, dst string, channels int) string {
+	if !f.isAuto() {
+		return fmt.Sprintf("retval, %s = cv.threshold(%s, %.1f, 255.0, cv.%s)", dst, src, float32(f.threshold.Pos()), f.typ.OpenCV())
+	}
+
+	gray, code := grayPythonCode(src, dst, channels)
+	return code + fmt.Sprintf("%s_thresh, %s = cv.threshold(%s, 0.0, 255.0, cv.%s | cv.%s)",
+		dst, dst, gray, f.typ.OpenCV(), f.auto.OpenCV())
+}
+
+func (f *thresholdFilter) CppCode(src, dst string, channels int) string {
+	if !f.isAuto() {
+		return fmt.Sprintf("cv::threshold(%s, %s, %.1f, 255.0, %s);", src, dst, float32(f.threshold.Pos()), f.typ.Cpp())
+	}
+
+	gray, code := grayCppCode(src, dst, channels)
+	return code + fmt.Sprintf("double %sThresh = cv::threshold(%s, %s, 0.0, 255.0, %s | %s);\n(void)%sThresh; // the computed threshold",
+		dst, gray, dst, f.typ.Cpp(), f.auto.Cpp(), dst)
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThresholdAutoCode(t *testing.T) {
+	f := newThresholdFilter().(*thresholdFilter)
+	f.auto.Next()
+
+	// the computed threshold is kept without any unused variables
+	tests := []struct {
+		lang string
+		code string
+		want []string
+	}{
+		{"Go", f.GoCode("src", "dest", bgrChannels), []string{"gocv.ThresholdBinary|gocv.ThresholdOtsu", "_ = destThresh"}},
+		{"Python", f.PythonCode("src", "dest", bgrChannels), []string{"cv.THRESH_BINARY | cv.THRESH_OTSU", "dest_thresh, dest = "}},
+		{"C++", f.CppCode("src", "dest", bgrChannels), []string{"cv::THRESH_BINARY | cv::THRESH_OTSU", "(void)destThresh;"}},
+	}
+
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.code, want) {
+				t.Errorf("%s: code does not contain %q:\n%s", tt.lang, want, tt.code)
+			}
+		}
+	}
+}
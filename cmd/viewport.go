@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// zoomLevels are the magnifications for the displayed image.
+var zoomLevels = []int{1, 2, 4, 8, 16}
+
+var inspectColor = color.RGBA{0, 255, 255, 0}
+
+// viewport is the part of an image of the size w by h that is shown when it
+// is zoomed, centered on the pixel being inspected where possible.
+func (r *runner) viewport(w, h int) image.Rectangle {
+	zoom := zoomLevels[r.zoom]
+	vw, vh := w/zoom, h/zoom
+
+	x := clampInt(r.center.X-vw/2, 0, w-vw)
+	y := clampInt(r.center.Y-vh/2, 0, h-vh)
+	return image.Rect(x, y, x+vw, y+vh)
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// zoomImage magnifies the viewport of the img using the nearest pixels, so
+// that single pixels can be seen. The center is moved to the center of the
+// img when it is not within the img, such as before the first frame.
+func (r *runner) zoomImage(img *gocv.Mat) {
+	w, h := img.Cols(), img.Rows()
+	if r.center.X < 0 || r.center.X >= w || r.center.Y < 0 || r.center.Y >= h {
+		r.center = image.Pt(w/2, h/2)
+	}
+
+	if zoomLevels[r.zoom] == 1 {
+		return
+	}
+
+	region := img.Region(r.viewport(w, h))
+	defer region.Close()
+
+	zoomed := gocv.NewMat()
+	defer zoomed.Close()
+	gocv.Resize(region, &zoomed, image.Pt(w, h), 0, 0, gocv.InterpolationNearestNeighbor)
+	zoomed.CopyTo(img)
+}
+
+// inspected is the pixel of an image of the size w by h that is under the
+// mouse, taking the zoom into account, or the pixel in the center of the view
+// when the mouse is not over the image.
+func (r *runner) inspected(w, h int) image.Point {
+	pos := r.mouse
+	if !pos.In(image.Rect(0, 0, w, h)) {
+		pos = image.Pt(w/2, h/2)
+	}
+
+	view := r.viewport(w, h)
+	return image.Pt(view.Min.X+pos.X*view.Dx()/w, view.Min.Y+pos.Y*view.Dy()/h)
+}
+
+// drawInspector marks the pixel being inspected on the displayed image, when
+// the pixel inspector is on.
+func (r *runner) drawInspector() {
+	if !r.inspect {
+		return
+	}
+
+	w, h := r.display.Cols(), r.display.Rows()
+	view := r.viewport(w, h)
+	pixel := r.inspected(w, h)
+
+	toBGR(r.display, &r.display)
+	zoom := zoomLevels[r.zoom]
+	x := (pixel.X-view.Min.X)*w/view.Dx() + zoom/2
+	y := (pixel.Y-view.Min.Y)*h/view.Dy() + zoom/2
+	gap := zoom/2 + 2
+	gocv.Line(&r.display, image.Pt(x-gap-10, y), image.Pt(x-gap, y), inspectColor, 1)
+	gocv.Line(&r.display, image.Pt(x+gap, y), image.Pt(x+gap+10, y), inspectColor, 1)
+	gocv.Line(&r.display, image.Pt(x, y-gap-10), image.Pt(x, y-gap), inspectColor, 1)
+	gocv.Line(&r.display, image.Pt(x, y+gap), image.Pt(x, y+gap+10), inspectColor, 1)
+}
+
+func (r *runner) handleZoom(offset int) {
+	r.zoom = clampInt(r.zoom+offset, 0, len(zoomLevels)-1)
+}
+
+// handlePan moves the center of the viewport by a quarter of the viewport.
+func (r *runner) handlePan(dx, dy int) {
+	step := r.viewport(r.display.Cols(), r.display.Rows()).Dx() / 4
+	if step < 1 {
+		step = 1
+	}
+
+	r.center = image.Pt(
+		clampInt(r.center.X+dx*step, 0, r.display.Cols()-1),
+		clampInt(r.center.Y+dy*step, 0, r.display.Rows()-1),
+	)
+}
+
+func (r *runner) handleInspect() {
+	r.inspect = !r.inspect
+}
+
+// handleMouse keeps track of where the mouse is in the displayed image, for
+// the pixel inspector.
+func (r *runner) handleMouse(pos image.Point) {
+	r.mouse = pos
+}
+
+// inspectLines are the lines of the overlay for the pixel inspector, with the
+// original and processed values of the pixel. The processed value is the raw
+// value from the filter, such as the CV16S value from sobel.
+func (r *runner) inspectLines() []string {
+	// in the side by side view, the same pixel is inspected in both images
+	pixel := r.inspected(r.display.Cols(), r.display.Rows())
+	x, y := pixel.X, pixel.Y
+	if r.view == viewSideBySide && r.img.Cols() > 0 {
+		x %= r.img.Cols()
+	}
+
+	return []string{
+		fmt.Sprintf("pixel: %d, %d", x, y),
+		"original: " + pixelValue(r.img, x, y),
+		"processed: " + pixelValue(r.processed, x, y),
+	}
+}
+
+// pixelValue describes the value of a pixel, with a value for each channel.
+func pixelValue(img gocv.Mat, x, y int) string {
+	if x < 0 || y < 0 || x >= img.Cols() || y >= img.Rows() {
+		return "-"
+	}
+
+	channels := img.Channels()
+	var values []string
+	for c := 0; c < channels; c++ {
+		// multiple channels are stored next to each other in the row
+		col := x*channels + c
+		switch depth(img) {
+		case gocv.MatTypeCV8U:
+			values = append(values, fmt.Sprint(img.GetUCharAt(y, col)))
+		case gocv.MatTypeCV16S:
+			values = append(values, fmt.Sprint(img.GetShortAt(y, col)))
+		case gocv.MatTypeCV32S:
+			values = append(values, fmt.Sprint(img.GetIntAt(y, col)))
+		case gocv.MatTypeCV32F:
+			values = append(values, fmt.Sprintf("%.2f", img.GetFloatAt(y, col)))
+		default:
+			values = append(values, "?")
+		}
+	}
+
+	if channels == 1 {
+		return values[0]
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"image"
+	"testing"
+)
+
+func TestViewport(t *testing.T) {
+	tests := []struct {
+		zoom   int
+		center image.Point
+		want   image.Rectangle
+	}{
+		{0, image.Pt(320, 240), image.Rect(0, 0, 640, 480)},
+		{1, image.Pt(320, 240), image.Rect(160, 120, 480, 360)},
+		{2, image.Pt(100, 100), image.Rect(20, 40, 180, 160)},
+
+		// the viewport stays within the image at the edges
+		{1, image.Pt(0, 0), image.Rect(0, 0, 320, 240)},
+		{1, image.Pt(639, 479), image.Rect(320, 240, 640, 480)},
+		{4, image.Pt(639, 0), image.Rect(600, 0, 640, 30)},
+	}
+
+	for _, tt := range tests {
+		r := &runner{zoom: tt.zoom, center: tt.center}
+		if got := r.viewport(640, 480); got != tt.want {
+			t.Errorf("viewport() at zoom %dx centered on %v = %v, want %v", zoomLevels[tt.zoom], tt.center, got, tt.want)
+		}
+	}
+}
+
+func TestInspected(t *testing.T) {
+	tests := []struct {
+		zoom   int
+		center image.Point
+		mouse  image.Point
+		want   image.Point
+	}{
+		{0, image.Pt(320, 240), image.Pt(10, 20), image.Pt(10, 20)},
+		{0, image.Pt(320, 240), image.Pt(639, 479), image.Pt(639, 479)},
+
+		// the center of the view is inspected until the mouse is over the image
+		{0, image.Pt(320, 240), image.Pt(-1, -1), image.Pt(320, 240)},
+		{1, image.Pt(100, 100), image.Pt(640, 0), image.Pt(160, 120)},
+
+		// the pixel under the mouse in the zoomed view
+		{1, image.Pt(320, 240), image.Pt(0, 0), image.Pt(160, 120)},
+		{1, image.Pt(320, 240), image.Pt(1, 1), image.Pt(160, 120)},
+		{1, image.Pt(320, 240), image.Pt(639, 479), image.Pt(479, 359)},
+		{2, image.Pt(0, 0), image.Pt(100, 100), image.Pt(25, 25)},
+	}
+
+	for _, tt := range tests {
+		r := &runner{zoom: tt.zoom, center: tt.center, mouse: tt.mouse}
+		if got := r.inspected(640, 480); got != tt.want {
+			t.Errorf("inspected() at zoom %dx centered on %v with the mouse at %v = %v, want %v",
+				zoomLevels[tt.zoom], tt.center, tt.mouse, got, tt.want)
+		}
+	}
+}
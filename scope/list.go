@@ -0,0 +1,110 @@
+package scope
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// listImages returns the image files in sorted order when src is a directory
+// or a glob, or nothing for any other kind of source. Only the files that have
+// the extension of an image file are included. Sources with a URL scheme, such
+// as streams, are never globs, and globs that do not match any image files are
+// left for OpenCV to open, since they may be some other kind of source.
+func listImages(src string) ([]string, error) {
+	var files []string
+
+	info, err := os.Stat(src)
+	switch {
+	case err == nil && info.IsDir():
+		entries, err := ioutil.ReadDir(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Mode().IsRegular() && isImageFile(e.Name()) {
+				files = append(files, filepath.Join(src, e.Name()))
+			}
+		}
+	case err != nil && !strings.Contains(src, "://") && strings.ContainsAny(src, "*?["):
+		matches, err := filepath.Glob(src)
+		if err != nil {
+			return nil, nil
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() && isImageFile(m) {
+				files = append(files, m)
+			}
+		}
+		if len(files) == 0 {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no image files found: %v", src)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Count is the number of image files when the source is a directory or a
+// glob, otherwise it is 0.
+func (s *Source) Count() int {
+	return len(s.files)
+}
+
+// Index is the index of the current image file.
+func (s *Source) Index() int {
+	return s.index
+}
+
+// FileName is the name of the current image file, without the directory.
+func (s *Source) FileName() string {
+	if len(s.files) == 0 {
+		return ""
+	}
+	return filepath.Base(s.files[s.index])
+}
+
+// SetIndex changes the current image file. If the image cannot be read, the
+// index is still changed but the previous image is kept.
+func (s *Source) SetIndex(index int) error {
+	if len(s.files) == 0 {
+		return nil
+	}
+
+	s.index = index
+	img, ok, err := readImage(s.files[index])
+	if !ok {
+		if err == nil {
+			err = fmt.Errorf("unable to read image file: %v", s.files[index])
+		}
+		return err
+	}
+
+	s.still.Close()
+	s.still = img
+	return nil
+}
+
+// Next changes to the next image file, wrapping around to the first one.
+func (s *Source) Next() error {
+	if len(s.files) == 0 {
+		return nil
+	}
+	return s.SetIndex((s.index + 1) % len(s.files))
+}
+
+// Prev changes to the previous image file, wrapping around to the last one.
+func (s *Source) Prev() error {
+	if len(s.files) == 0 {
+		return nil
+	}
+	return s.SetIndex((s.index + len(s.files) - 1) % len(s.files))
+}
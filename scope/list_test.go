@@ -0,0 +1,66 @@
+package scope
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cvscope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"b.png", "a.jpg", "README", "notes.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	images := []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.png")}
+
+	tests := []struct {
+		src     string
+		want    []string
+		wantErr bool
+	}{
+		{src: dir, want: images},
+		{src: filepath.Join(dir, "*"), want: images},
+		{src: filepath.Join(dir, "*.png"), want: images[1:]},
+
+		// globs without any image files are left for OpenCV
+		{src: filepath.Join(dir, "*.txt")},
+		{src: filepath.Join(dir, "*.gif")},
+
+		// streams are never globs
+		{src: "http://cam/video.mjpg?res=640x480"},
+		{src: "rtsp://[::1]:8554/live"},
+
+		{src: "0"},
+		{src: "video.mp4"},
+	}
+
+	for _, tt := range tests {
+		got, err := listImages(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("listImages(%q) error = %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("listImages(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+
+	empty, err := ioutil.TempDir("", "cvscope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+
+	if _, err := listImages(empty); err == nil {
+		t.Errorf("listImages(%q) of a directory without images did not fail", empty)
+	}
+}
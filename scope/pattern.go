@@ -0,0 +1,214 @@
+package scope
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// patternPrefix is the prefix for sources that are synthetic test patterns,
+// such as pattern:checkerboard.
+const patternPrefix = "pattern:"
+
+const (
+	patternWidth  = 640
+	patternHeight = 480
+
+	// patternFPS is the frame rate for the patterns that move.
+	patternFPS = 30
+)
+
+// pattern draws a synthetic test pattern. Patterns that move draw a different
+// image for each frame, while the others only draw a single image.
+type pattern struct {
+	draw   func(img *gocv.Mat, frame int)
+	moving bool
+}
+
+// patterns are the synthetic test patterns, by name. All of the patterns are
+// deterministic, so they always produce the same images.
+var patterns = map[string]pattern{
+	"checkerboard":      {draw: still(checkerboard)},
+	"gradient":          {draw: still(horizontalGradient)},
+	"vertical-gradient": {draw: still(verticalGradient)},
+	"star":              {draw: still(siemensStar)},
+	"gaussian-noise":    {draw: noise(gaussianNoise)},
+	"salt-pepper-noise": {draw: noise(saltPepperNoise)},
+	"shapes":            {draw: movingShapes, moving: true},
+	"text":              {draw: text},
+}
+
+// PatternNames are the names of the synthetic test patterns.
+func PatternNames() []string {
+	var names []string
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// openPattern opens a source for the synthetic test pattern with the name.
+func (s *Source) openPattern(name string) error {
+	p, ok := patterns[name]
+	if !ok {
+		return fmt.Errorf("unknown pattern %s, must be one of %s", name, strings.Join(PatternNames(), ", "))
+	}
+
+	if p.moving {
+		s.pattern = &p
+		return nil
+	}
+
+	s.still = gocv.NewMat()
+	s.isImage = true
+	p.draw(&s.still, 0)
+	return nil
+}
+
+// still draws a pattern that is generated pixel by pixel as an 8-bit BGR image.
+func still(pixel func(x, y int) (b, g, r uint8)) func(img *gocv.Mat, frame int) {
+	return func(img *gocv.Mat, frame int) {
+		data := make([]byte, 0, patternWidth*patternHeight*3)
+		for y := 0; y < patternHeight; y++ {
+			for x := 0; x < patternWidth; x++ {
+				b, g, r := pixel(x, y)
+				data = append(data, b, g, r)
+			}
+		}
+
+		m, err := gocv.NewMatFromBytes(patternHeight, patternWidth, gocv.MatTypeCV8UC3, data)
+		if err != nil {
+			return
+		}
+		defer m.Close()
+		m.CopyTo(img)
+	}
+}
+
+func gray(v uint8) (b, g, r uint8) {
+	return v, v, v
+}
+
+// checkerboard has black and white squares that are 40 pixels across.
+func checkerboard(x, y int) (b, g, r uint8) {
+	if (x/40+y/40)%2 == 0 {
+		return gray(255)
+	}
+	return gray(0)
+}
+
+// horizontalGradient ramps from black on the left to white on the right.
+func horizontalGradient(x, y int) (b, g, r uint8) {
+	return gray(uint8(x * 255 / (patternWidth - 1)))
+}
+
+// verticalGradient ramps from black at the top to white at the bottom.
+func verticalGradient(x, y int) (b, g, r uint8) {
+	return gray(uint8(y * 255 / (patternHeight - 1)))
+}
+
+// siemensStar has 36 pairs of black and white spokes, which get closer
+// together towards the center, on a gray background.
+func siemensStar(x, y int) (b, g, r uint8) {
+	dx, dy := float64(x-patternWidth/2), float64(y-patternHeight/2)
+	if math.Hypot(dx, dy) > patternHeight/2-10 {
+		return gray(128)
+	}
+
+	angle := math.Atan2(dy, dx) + math.Pi
+	if int(angle/(2*math.Pi)*72)%2 == 0 {
+		return gray(255)
+	}
+	return gray(0)
+}
+
+// noise draws the checkerboard in dark and light gray with noise added to
+// it, using the same random numbers each time the pattern is drawn.
+func noise(add func(rnd *rand.Rand, v uint8) uint8) func(img *gocv.Mat, frame int) {
+	return func(img *gocv.Mat, frame int) {
+		rnd := rand.New(rand.NewSource(1))
+		still(func(x, y int) (b, g, r uint8) {
+			v, _, _ := checkerboard(x, y)
+			return gray(add(rnd, v/2+64))
+		})(img, frame)
+	}
+}
+
+// gaussianNoise adds Gaussian noise with a standard deviation of 30.
+func gaussianNoise(rnd *rand.Rand, v uint8) uint8 {
+	n := float64(v) + rnd.NormFloat64()*30
+	return uint8(math.Max(0, math.Min(255, n)))
+}
+
+// saltPepperNoise sets 5% of the pixels to black or white at random.
+func saltPepperNoise(rnd *rand.Rand, v uint8) uint8 {
+	switch n := rnd.Float64(); {
+	case n < 0.025:
+		return 0
+	case n < 0.05:
+		return 255
+	}
+	return v
+}
+
+var (
+	background = gocv.NewScalar(48, 48, 48, 0)
+	white      = color.RGBA{255, 255, 255, 0}
+	red        = color.RGBA{0, 0, 255, 0}
+	green      = color.RGBA{0, 255, 0, 0}
+	blue       = color.RGBA{255, 0, 0, 0}
+)
+
+// movingShapes has a circle moving from side to side, a square moving up and
+// down, and a line rotating around the center, on a dark gray background.
+func movingShapes(img *gocv.Mat, frame int) {
+	bg := gocv.NewMatWithSizeFromScalar(background, patternHeight, patternWidth, gocv.MatTypeCV8UC3)
+	defer bg.Close()
+
+	t := float64(frame) / patternFPS
+	cx := patternWidth/2 + int(240*math.Sin(t))
+	gocv.Circle(&bg, image.Pt(cx, 120), 40, white, -1)
+
+	sy := patternHeight/2 + int(160*math.Sin(t*1.3))
+	gocv.Rectangle(&bg, image.Rect(420, sy-30, 480, sy+30), red, -1)
+
+	center := image.Pt(patternWidth/4, patternHeight*2/3)
+	end := image.Pt(center.X+int(100*math.Cos(t)), center.Y+int(100*math.Sin(t)))
+	gocv.Line(&bg, center, end, green, 4)
+	gocv.Circle(&bg, center, 100, blue, 2)
+
+	bg.CopyTo(img)
+}
+
+// text has lines of text in several fonts and sizes, in white on black.
+func text(img *gocv.Mat, frame int) {
+	bg := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), patternHeight, patternWidth, gocv.MatTypeCV8UC3)
+	defer bg.Close()
+
+	lines := []struct {
+		font  gocv.HersheyFont
+		scale float64
+	}{
+		{gocv.FontHersheyPlain, 1},
+		{gocv.FontHersheySimplex, 0.5},
+		{gocv.FontHersheySimplex, 1},
+		{gocv.FontHersheyDuplex, 1.5},
+		{gocv.FontHersheySimplex, 2},
+	}
+
+	y := 20
+	for _, l := range lines {
+		size := gocv.GetTextSize("CVscope 0123", l.font, l.scale, 1)
+		y += size.Y + 30
+		gocv.PutText(&bg, "CVscope 0123", image.Pt(20, y), l.font, l.scale, white, 1)
+	}
+
+	bg.CopyTo(img)
+}
@@ -0,0 +1,75 @@
+package scope
+
+import (
+	"bytes"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+func TestPatternPixels(t *testing.T) {
+	tests := []struct {
+		name  string
+		pixel func(x, y int) (b, g, r uint8)
+		x, y  int
+		want  uint8
+	}{
+		{"checkerboard", checkerboard, 0, 0, 255},
+		{"checkerboard", checkerboard, 39, 39, 255},
+		{"checkerboard", checkerboard, 40, 0, 0},
+		{"checkerboard", checkerboard, 0, 40, 0},
+		{"checkerboard", checkerboard, 40, 40, 255},
+		{"gradient", horizontalGradient, 0, 100, 0},
+		{"gradient", horizontalGradient, patternWidth - 1, 100, 255},
+		{"vertical-gradient", verticalGradient, 100, 0, 0},
+		{"vertical-gradient", verticalGradient, 100, patternHeight - 1, 255},
+
+		// the star is on a gray background
+		{"star", siemensStar, 0, 0, 128},
+	}
+
+	for _, tt := range tests {
+		b, g, r := tt.pixel(tt.x, tt.y)
+		if b != tt.want || g != tt.want || r != tt.want {
+			t.Errorf("%s at %d, %d = %d, %d, %d, want %d", tt.name, tt.x, tt.y, b, g, r, tt.want)
+		}
+	}
+}
+
+// TestPatternsDeterministic checks that every pattern draws the same images
+// each time it is opened, so that they can be used to check filters.
+func TestPatternsDeterministic(t *testing.T) {
+	read := func(name string) [][]byte {
+		s, err := OpenVideoCapture(patternPrefix + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		img := gocv.NewMat()
+		defer img.Close()
+
+		var frames [][]byte
+		for i := 0; i < 3; i++ {
+			if ok := s.Read(&img); !ok || img.Empty() {
+				t.Fatalf("%s: no image read for frame %d", name, i)
+			}
+			frames = append(frames, img.ToBytes())
+		}
+		return frames
+	}
+
+	for _, name := range PatternNames() {
+		first, second := read(name), read(name)
+		for i := range first {
+			if !bytes.Equal(first[i], second[i]) {
+				t.Errorf("%s: frame %d is different each time", name, i)
+			}
+		}
+
+		moved := !bytes.Equal(first[0], first[1])
+		if moved != patterns[name].moving {
+			t.Errorf("%s: frames moved = %v, want %v", name, moved, patterns[name].moving)
+		}
+	}
+}
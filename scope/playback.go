@@ -0,0 +1,108 @@
+package scope
+
+import "gocv.io/x/gocv"
+
+// IsFile reports whether the source is a video file, which has a known number
+// of frames and can be seeked, unlike a camera or a stream.
+func (s *Source) IsFile() bool {
+	return s.FrameCount() > 0
+}
+
+// FrameCount is the number of frames in a video file, otherwise it is 0.
+func (s *Source) FrameCount() int {
+	if s.video == nil {
+		return 0
+	}
+
+	count := int(s.video.Get(gocv.VideoCaptureFrameCount))
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// Position is the index of the next frame that will be read from a video file.
+func (s *Source) Position() int {
+	if s.video == nil {
+		return 0
+	}
+	return int(s.video.Get(gocv.VideoCapturePosFrames))
+}
+
+// FPS is the number of frames per second for a video file or a moving test
+// pattern, or 0 when it is not known.
+func (s *Source) FPS() float64 {
+	if s.pattern != nil {
+		return patternFPS
+	}
+	if s.video == nil {
+		return 0
+	}
+	return s.video.Get(gocv.VideoCaptureFPS)
+}
+
+// Seek changes the next frame that will be read from a video file.
+func (s *Source) Seek(frame int) {
+	if !s.IsFile() {
+		return
+	}
+	s.video.Set(gocv.VideoCapturePosFrames, float64(clampFrame(frame, s.FrameCount())))
+}
+
+// clampFrame keeps the frame within a video file with count frames.
+func clampFrame(frame, count int) int {
+	if frame >= count {
+		frame = count - 1
+	}
+	if frame < 0 {
+		frame = 0
+	}
+	return frame
+}
+
+// Step reads the frame that is offset from the frame that was last read, so
+// an offset of 1 reads the next frame and an offset of -1 the previous one.
+// Stepping does not loop, so when there is no frame to step to, such as past
+// the end of the file, img and the position are left as they are.
+func (s *Source) Step(offset int, img *gocv.Mat) bool {
+	frame, ok := stepFrame(s.Position(), offset, s.FrameCount())
+	if !ok {
+		return false
+	}
+	if offset != 1 {
+		s.Seek(frame)
+	}
+
+	// OpenCV empties the Mat when the read fails
+	next := gocv.NewMat()
+	defer next.Close()
+	if ok := s.video.Read(&next); !ok || next.Empty() {
+		return false
+	}
+	next.CopyTo(img)
+	return true
+}
+
+// stepFrame returns the frame that is offset from the frame that was last
+// read, given the position of the next frame, and whether the frame is within
+// a video file with count frames.
+func stepFrame(position, offset, count int) (int, bool) {
+	frame := position - 1 + offset
+	return frame, frame >= 0 && frame < count
+}
+
+// Loop reports whether a video file starts again once it reaches the end.
+func (s *Source) Loop() bool {
+	return s.loop
+}
+
+// SetLoop changes whether a video file starts again once it reaches the end.
+func (s *Source) SetLoop(loop bool) {
+	s.loop = loop
+}
+
+// IsTimed reports whether the source has to be played at its FPS, since it
+// does not wait for each frame like a camera or a stream.
+func (s *Source) IsTimed() bool {
+	return s.IsFile() || s.pattern != nil
+}
@@ -0,0 +1,51 @@
+package scope
+
+import "testing"
+
+func TestClampFrame(t *testing.T) {
+	tests := []struct {
+		frame, count, want int
+	}{
+		{0, 100, 0},
+		{50, 100, 50},
+		{99, 100, 99},
+		{100, 100, 99},
+		{250, 100, 99},
+		{-1, 100, 0},
+		{-50, 100, 0},
+		{0, 1, 0},
+		{5, 1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := clampFrame(tt.frame, tt.count); got != tt.want {
+			t.Errorf("clampFrame(%d, %d) = %d, want %d", tt.frame, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestStepFrame(t *testing.T) {
+	tests := []struct {
+		name                    string
+		position, offset, count int
+		want                    int
+		ok                      bool
+	}{
+		{"next", 10, 1, 100, 10, true},
+		{"previous", 10, -1, 100, 8, true},
+		{"second frame back to the first", 2, -1, 100, 0, true},
+		{"first frame back", 1, -1, 100, -1, false},
+		{"last frame forward", 100, 1, 100, 100, false},
+		{"last frame back", 100, -1, 100, 98, true},
+		{"before the first read", 0, 1, 100, 0, true},
+		{"not a video file", 0, 1, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := stepFrame(tt.position, tt.offset, tt.count)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: stepFrame(%d, %d, %d) = %d, %v, want %d, %v",
+				tt.name, tt.position, tt.offset, tt.count, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,43 +1,188 @@
 package scope
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"gocv.io/x/gocv"
 )
 
+// imageExts are the file extensions for still images that OpenCV can read.
+var imageExts = map[string]bool{
+	".bmp": true, ".dib": true,
+	".jpg": true, ".jpeg": true, ".jpe": true, ".jp2": true,
+	".png": true, ".webp": true,
+	".tif": true, ".tiff": true,
+	".pbm": true, ".pgm": true, ".ppm": true, ".pnm": true, ".pxm": true,
+	".sr": true, ".ras": true,
+	".exr": true, ".hdr": true, ".pic": true,
+}
+
 // Source is a wrapper around video source that can cleanly handle displaying images.
 type Source struct {
-	src         string
-	video       *gocv.VideoCapture
-	isImage     bool
-	gotFirstImg bool
+	src   string
+	video *gocv.VideoCapture
+
+	// still is the image when the source is an image file, which is returned
+	// every time a frame is read.
+	still   gocv.Mat
+	isImage bool
+
+	// files are the image files when the source is a directory or a glob, with
+	// index being the file for the current still image.
+	files []string
+	index int
+
+	// loop is set when a video file starts again from the beginning once it
+	// reaches the end.
+	loop bool
+
+	// pattern is the synthetic test pattern when it is one that moves, with
+	// frame being the number of the next frame to draw.
+	pattern *pattern
+	frame   int
 }
 
-// OpenVideoCapture opens a source
+// OpenVideoCapture opens a source, which is either a still image file, a
+// directory or glob of image files, a synthetic test pattern such as
+// pattern:checkerboard, or anything that can be opened using
+// gocv.OpenVideoCapture. Image files are detected using their extension, or
+// else using their content.
 func OpenVideoCapture(src string) (s *Source, err error) {
 	s = &Source{src: src}
 
-	if strings.Contains(src, ".jpg") {
-		s.isImage = true
-		s.gotFirstImg = false
+	if strings.HasPrefix(src, patternPrefix) {
+		err = s.openPattern(strings.TrimPrefix(src, patternPrefix))
+		return
+	}
+
+	if s.files, err = listImages(src); err != nil || len(s.files) > 0 {
+		if err == nil {
+			s.isImage = true
+			err = s.SetIndex(0)
+		}
+		return
+	}
+
+	var ok bool
+	if s.still, ok, err = readImage(src); ok || err != nil {
+		s.isImage = ok
+		return
 	}
+
 	s.video, err = gocv.OpenVideoCapture(s.src)
 	return
 }
 
-// Read video
-func (s *Source) Read(img *gocv.Mat) bool {
-	if !s.isImage || !s.gotFirstImg {
-		if ok := s.video.Read(img); !ok {
-			return false
+// readImage reads the image file, if src is an image file. Files with the
+// extension of an image file must be readable as an image, while other files
+// are only checked to see if their content is an image.
+func readImage(src string) (img gocv.Mat, ok bool, err error) {
+	if info, err := os.Stat(src); err != nil || !info.Mode().IsRegular() {
+		return img, false, nil
+	}
+
+	raw := gocv.IMRead(src, gocv.IMReadUnchanged)
+	defer raw.Close()
+	if raw.Empty() {
+		if isImageFile(src) {
+			return img, false, fmt.Errorf("unable to read image file: %v", src)
 		}
-		s.gotFirstImg = true
+		return img, false, nil
 	}
-	return true
+
+	img = gocv.NewMat()
+	toDisplayable(raw, &img)
+	return img, true, nil
+}
+
+// isImageFile reports whether the file name has the extension of a still
+// image file.
+func isImageFile(name string) bool {
+	return imageExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// toDisplayable converts an image to 8-bit so that it works with all of the
+// filters. Images with an alpha channel are blended onto a black background,
+// so that transparent areas do not show hidden color data.
+func toDisplayable(src gocv.Mat, dst *gocv.Mat) {
+	img := gocv.NewMat()
+	defer img.Close()
+
+	switch src.Type() & 7 {
+	case gocv.MatTypeCV8U:
+		src.CopyTo(&img)
+	case gocv.MatTypeCV16U:
+		src.ConvertToWithParams(&img, gocv.MatTypeCV8U, 1.0/257, 0)
+	default:
+		// floating point images, such as EXR and HDR, range from 0.0 to 1.0
+		src.ConvertToWithParams(&img, gocv.MatTypeCV8U, 255, 0)
+	}
+
+	if img.Channels() != 4 {
+		img.CopyTo(dst)
+		return
+	}
+
+	channels := gocv.Split(img)
+	defer func() {
+		for _, c := range channels {
+			c.Close()
+		}
+	}()
+
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	gocv.Merge(channels[:3], &bgr)
+	bgr.ConvertTo(&bgr, gocv.MatTypeCV32F)
+
+	alpha := gocv.NewMat()
+	defer alpha.Close()
+	gocv.Merge([]gocv.Mat{channels[3], channels[3], channels[3]}, &alpha)
+	alpha.ConvertToWithParams(&alpha, gocv.MatTypeCV32F, 1.0/255, 0)
+
+	blended := gocv.NewMat()
+	defer blended.Close()
+	gocv.Multiply(bgr, alpha, &blended)
+	blended.ConvertTo(dst, gocv.MatTypeCV8U)
+}
+
+// IsImage reports whether the source is a still image.
+func (s *Source) IsImage() bool {
+	return s.isImage
+}
+
+// Read video, or a copy of the still image so that it can be processed again.
+func (s *Source) Read(img *gocv.Mat) bool {
+	if s.isImage {
+		s.still.CopyTo(img)
+		return true
+	}
+	if s.pattern != nil {
+		s.pattern.draw(img, s.frame)
+		s.frame++
+		return true
+	}
+	if s.video.Read(img) {
+		return true
+	}
+	if !s.loop || !s.IsFile() {
+		return false
+	}
+
+	s.Seek(0)
+	return s.video.Read(img)
 }
 
 // Close video
 func (s *Source) Close() error {
+	if s.isImage {
+		return s.still.Close()
+	}
+	if s.pattern != nil {
+		return nil
+	}
 	return s.video.Close()
 }
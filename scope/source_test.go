@@ -0,0 +1,135 @@
+package scope
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+func TestIsImageFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"photo.jpg", true},
+		{"photo.JPEG", true},
+		{"dir/scan.tiff", true},
+		{"mask.png", true},
+		{"web.webp", true},
+		{"hdr/room.exr", true},
+		{"video.mp4", false},
+		{"notes.txt", false},
+		{"jpg", false},
+		{"0", false},
+	}
+
+	for _, tt := range tests {
+		if got := isImageFile(tt.name); got != tt.want {
+			t.Errorf("isImageFile(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToDisplayable(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   gocv.MatType
+		value gocv.Scalar
+		want  []uint8
+	}{
+		{"8-bit", gocv.MatTypeCV8UC3, gocv.NewScalar(10, 20, 30, 0), []uint8{10, 20, 30}},
+		{"16-bit", gocv.MatTypeCV16UC1, gocv.NewScalar(100*257, 0, 0, 0), []uint8{100}},
+		{"floating point", gocv.MatTypeCV32FC1, gocv.NewScalar(0.2, 0, 0, 0), []uint8{51}},
+		{"opaque", gocv.MatTypeCV8UC4, gocv.NewScalar(200, 100, 50, 255), []uint8{200, 100, 50}},
+		{"transparent", gocv.MatTypeCV8UC4, gocv.NewScalar(200, 100, 50, 0), []uint8{0, 0, 0}},
+		{"translucent", gocv.MatTypeCV8UC4, gocv.NewScalar(200, 100, 50, 51), []uint8{40, 20, 10}},
+	}
+
+	for _, tt := range tests {
+		src := gocv.NewMatWithSizeFromScalar(tt.value, 2, 2, tt.typ)
+		dst := gocv.NewMat()
+		toDisplayable(src, &dst)
+
+		if dst.Type()&7 != gocv.MatTypeCV8U || dst.Channels() != len(tt.want) {
+			t.Errorf("%s: toDisplayable() type = %v, want 8-bit with %d channels", tt.name, dst.Type(), len(tt.want))
+		} else {
+			// the channels of the pixel at 1, 1 follow the pixel at 0, 1
+			for c, want := range tt.want {
+				if got := dst.GetUCharAt(1, len(tt.want)+c); got != want {
+					t.Errorf("%s: toDisplayable() channel %d = %d, want %d", tt.name, c, got, want)
+				}
+			}
+		}
+
+		src.Close()
+		dst.Close()
+	}
+}
+
+func TestReadImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cvscope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	img := image.NewGray(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.Gray{Y: 200})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"image.png":  buf.Bytes(),
+		"image":      buf.Bytes(),
+		"broken.png": []byte("not an image"),
+		"notes.txt":  []byte("not an image"),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ok      bool
+		wantErr bool
+	}{
+		{name: "image.png", ok: true},
+
+		// images are also detected by their content
+		{name: "image", ok: true},
+
+		// files with the extension of an image must be images
+		{name: "broken.png", wantErr: true},
+
+		{name: "notes.txt"},
+		{name: "missing.png"},
+		{name: "."},
+	}
+
+	for _, tt := range tests {
+		src := filepath.Join(dir, tt.name)
+		got, ok, err := readImage(src)
+		if ok != tt.ok || (err != nil) != tt.wantErr {
+			t.Errorf("readImage(%q) = %v, %v, want %v, error %v", tt.name, ok, err, tt.ok, tt.wantErr)
+		}
+		if !ok {
+			continue
+		}
+
+		if got.Cols() != 4 || got.Rows() != 3 || got.GetUCharAt(1, 1) != 200 {
+			t.Errorf("readImage(%q) read the wrong image", tt.name)
+		}
+		got.Close()
+	}
+}